@@ -0,0 +1,38 @@
+package rove
+
+import (
+	"fmt"
+
+	"github.com/josephspurrier/rove/pkg/changeset"
+)
+
+// checksum returns the checksum of the changeset with the checksum version. The
+// changes are normalized first if ChecksumNormalize is true.
+func (r *Rove) checksum(cs changeset.Record) (string, error) {
+	version := r.ChecksumVersion
+	if len(version) == 0 {
+		version = changeset.DefaultChecksum
+	}
+	if r.ChecksumNormalize {
+		version += changeset.ChecksumNormalized
+	}
+
+	s, err := cs.GenerateChecksumVersion(version)
+	if err != nil {
+		return "", fmt.Errorf("error on changeset %v:%v - %v", cs.Author, cs.ID, err)
+	}
+
+	return s, nil
+}
+
+// checksumChanged returns true if the checksum of the record in the changelog
+// doesn't match the changeset. The checksum is verified with the version it
+// was recorded with.
+func checksumChanged(cs changeset.Record, record changeset.Record) (bool, error) {
+	ok, err := cs.VerifyChecksum(record.Checksum)
+	if err != nil {
+		return false, fmt.Errorf("error on changeset %v:%v - %v", cs.Author, cs.ID, err)
+	}
+
+	return !ok, nil
+}
@@ -1,13 +1,19 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/josephspurrier/rove"
 	"github.com/josephspurrier/rove/pkg/adapter/mysql"
+	"github.com/josephspurrier/rove/pkg/adapter/postgres"
+	"github.com/josephspurrier/rove/pkg/adapter/sqlite"
 
+	"github.com/jmoiron/sqlx"
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
 )
 
@@ -15,47 +21,100 @@ const (
 	checksumError  = "error"
 	checksumIgnore = "ignore"
 	checksumUpdate = "update"
+
+	adapterMySQL    = "mysql"
+	adapterPostgres = "postgres"
+	adapterSQLite   = "sqlite"
 )
 
 var (
 	app       = kingpin.New("rove", "Performs database migration tasks.")
 	cChecksum = app.Flag("checksum-mode", "Set how to handle checksums that don't match "+
 		"[error (default),ignore,update].").Enum(checksumError, checksumIgnore, checksumUpdate)
+	cChecksumNormalize = app.Flag("checksum-normalize", "Ignore comments and whitespace "+
+		"in the checksums of changesets.").Default("false").Bool()
+
+	cAdapter = app.Flag("adapter", "Set the changelog adapter "+
+		"[mysql (default),postgres,sqlite].").Default(adapterMySQL).Enum(adapterMySQL,
+		adapterPostgres, adapterSQLite)
 
 	cDBHost      = app.Flag("hostname", "Database hostname or IP [string].").String()
 	cDBPort      = app.Flag("port", "Database port [int].").Int()
 	cDBUsername  = app.Flag("username", "Database username [string].").String()
 	cDBPassword  = app.Flag("password", "Database password [string].").String()
-	cDBName      = app.Flag("name", "Database name [string].").String()
+	cDBName      = app.Flag("name", "Database name or SQLite filename [string].").String()
 	cDBParameter = app.Flag("parameter", "Database parameters [string].").String()
+	cDBSchema    = app.Flag("schema", "PostgreSQL schema for the changelog table [string].").String()
+
+	cLockWait  = app.Flag("lock-wait", "How long to wait for the changelog lock [duration].").Default("5m").Duration()
+	cLockStale = app.Flag("lock-stale", "Take over a changelog lock held longer than the longest migration, 0 to disable [duration].").Default("0").Duration()
+
+	cContexts = app.Flag("contexts", "Only apply changesets with contexts that match the expression, like \"dev and !test\" [string].").Default("").String()
+	cLabels   = app.Flag("labels", "Only apply changesets with labels that match the expression, like \"seed or fixture\" [string].").Default("").String()
+
+	cProperties = app.Flag("define", "Set a property in the changesets, like -D schema=app [key=value].").Short('D').StringMap()
 
 	cDBPrefix  = app.Flag("envprefix", "Prefix for environment variables.").String()
 	cDBAll     = app.Command("all", "Apply all changesets to the database.")
 	cDBAllFile = cDBAll.Arg("file", "Filename of the migration file [string].").Required().String()
+	cDBAllSQL  = cDBAll.Flag("sql", "Output the SQL instead of running it.").Default("false").Bool()
 
 	cDBUp      = app.Command("up", "Apply a specific number of changesets to the database.")
 	cDBUpCount = cDBUp.Arg("count", "Number of changesets [int].").Required().Int()
 	cDBUpFile  = cDBUp.Arg("file", "Filename of the migration file [string].").Required().String()
+	cDBUpSQL   = cDBUp.Flag("sql", "Output the SQL instead of running it.").Default("false").Bool()
+
+	cDBUpTo       = app.Command("up-to", "Apply the changesets up to and including a tag or a changeset to the database.")
+	cDBUpToTarget = cDBUpTo.Arg("target", "Tag from a tagDatabase in the file or a changeset in the format author:id [string].").Required().String()
+	cDBUpToFile   = cDBUpTo.Arg("file", "Filename of the migration file [string].").Required().String()
+	cDBUpToSQL    = cDBUpTo.Flag("sql", "Output the SQL instead of running it.").Default("false").Bool()
+
+	cDBSync     = app.Command("sync", "Mark the changesets as applied in the database without running them.")
+	cDBSyncFile = cDBSync.Arg("file", "Filename of the migration file [string].").Required().String()
+	cDBSyncTo   = cDBSync.Flag("to", "Tag from a tagDatabase in the file or a changeset in the format author:id to stop at [string].").Default("").String()
+	cDBSyncSQL  = cDBSync.Flag("sql", "Output the SQL instead of running it.").Default("false").Bool()
 
 	cDBReset     = app.Command("reset", "Apply all rollbacks to the database.")
 	cDBResetFile = cDBReset.Arg("file", "Filename of the migration file [string].").Required().String()
+	cDBResetSQL  = cDBReset.Flag("sql", "Output the SQL instead of running it.").Default("false").Bool()
 
 	cDBDown      = app.Command("down", "Apply a specific number of rollbacks to the database.")
 	cDBDownCount = cDBDown.Arg("count", "Number of rollbacks [int].").Required().Int()
 	cDBDownFile  = cDBDown.Arg("file", "Filename of the migration file [string].").Required().String()
+	cDBDownSQL   = cDBDown.Flag("sql", "Output the SQL instead of running it.").Default("false").Bool()
 
 	cDBTag     = app.Command("tag", "Apply a tag to the latest changeset in the database.")
 	cDBTagName = cDBTag.Arg("name", "Name of the tag [string].").Required().String()
 	cDBTagFile = cDBTag.Arg("file", "Filename of the migration file [string].").Required().String()
+	cDBTagAt   = cDBTag.Flag("at", "Apply the tag to the changeset in the format author:id[:file] instead [string].").Default("").String()
+
+	cDBTags = app.Command("tags", "List the tags in the database with their position and date.")
 
 	cDBRollback     = app.Command("rollback", "Run all rollbacks until the specified tag on the database.")
 	cDBRollbackName = cDBRollback.Arg("name", "Name of the tag [string].").Required().String()
 	cDBRollbackFile = cDBRollback.Arg("file", "Filename of the migration file [string].").Required().String()
+	cDBRollbackSQL  = cDBRollback.Flag("sql", "Output the SQL instead of running it.").Default("false").Bool()
+
+	cDBRollbackDate     = app.Command("rollback-to-date", "Run all rollbacks of the changesets applied after the date on the database.")
+	cDBRollbackDateDate = cDBRollbackDate.Arg("date", "Date in local time like \"2006-01-02 15:04:05\", \"15:04\" for today, or RFC 3339 [string].").Required().String()
+	cDBRollbackDateFile = cDBRollbackDate.Arg("file", "Filename of the migration file [string].").Required().String()
+	cDBRollbackDateSQL  = cDBRollbackDate.Flag("sql", "Output the SQL instead of running it.").Default("false").Bool()
+
+	cDBRollbackTo          = app.Command("rollback-to", "Run all rollbacks of the changesets applied after the changeset on the database.")
+	cDBRollbackToChangeset = cDBRollbackTo.Arg("changeset", "Changeset in the format author:id [string].").Required().String()
+	cDBRollbackToFile      = cDBRollbackTo.Arg("file", "Filename of the migration file [string].").Required().String()
+	cDBRollbackToFilename  = cDBRollbackTo.Flag("changeset-file", "Filename of the changeset in the changelog, defaults to the name of the migration file [string].").Default("").String()
+	cDBRollbackToSQL       = cDBRollbackTo.Flag("sql", "Output the SQL instead of running it.").Default("false").Bool()
 
 	cDBConvert     = app.Command("convert", "Convert a Liquibase changelog table to a Rove changelog table.")
 	cDBConvertFile = cDBConvert.Arg("file", "Filename of the migration file [string].").Required().String()
 
-	cDBStatus = app.Command("status", "Output the list of changesets already applied to the database.")
+	cDBStatus     = app.Command("status", "Compare the changesets in the file with the changesets applied to the database.")
+	cDBStatusFile = cDBStatus.Arg("file", "Filename of the migration file [string].").Required().String()
+
+	cDBLock        = app.Command("lock", "Manage the changelog lock.")
+	cDBLockStatus  = cDBLock.Command("status", "Output the owner of the changelog lock.")
+	cDBLockRelease = cDBLock.Command("release", "Release the changelog lock regardless of the owner.")
 )
 
 func main() {
@@ -70,12 +129,148 @@ func main() {
 		csMode = rove.ChecksumUpdate
 	}
 
-	// Create the MySQL connection information from environment variables.
-	conn, err := mysql.NewConnection(*cDBPrefix)
+	// Create the changelog for the adapter.
+	db, sqldb, err := newChangelog(*cAdapter)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	// Create a new migration with the settings from the flags.
+	newMigration := func(filename string) *rove.Rove {
+		r := rove.NewFileMigration(db, filename)
+		r.Verbose = true
+		r.Checksum = csMode
+		r.ChecksumNormalize = *cChecksumNormalize
+		r.LockWait = *cLockWait
+		r.LockStale = *cLockStale
+		r.Contexts = *cContexts
+		r.Labels = *cLabels
+		r.Properties = *cProperties
+		r.EnvPrefix = *cDBPrefix
+		return r
+	}
+
+	// Create a new migration that outputs the SQL instead of running it.
+	newDryRun := func(filename string, dryRun bool) *rove.Rove {
+		r := newMigration(filename)
+		if dryRun {
+			r.Verbose = false
+			r.DryRun = os.Stdout
+		}
+		return r
+	}
+
+	switch arg {
+	case cDBAll.FullCommand():
+		err = newDryRun(*cDBAllFile, *cDBAllSQL).Migrate(0)
+	case cDBUp.FullCommand():
+		err = newDryRun(*cDBUpFile, *cDBUpSQL).Migrate(*cDBUpCount)
+	case cDBUpTo.FullCommand():
+		err = newDryRun(*cDBUpToFile, *cDBUpToSQL).MigrateTo(*cDBUpToTarget)
+	case cDBSync.FullCommand():
+		err = newDryRun(*cDBSyncFile, *cDBSyncSQL).MarkApplied(*cDBSyncTo)
+	case cDBReset.FullCommand():
+		err = newDryRun(*cDBResetFile, *cDBResetSQL).Reset(0)
+	case cDBDown.FullCommand():
+		err = newDryRun(*cDBDownFile, *cDBDownSQL).Reset(*cDBDownCount)
+	case cDBTag.FullCommand():
+		if len(*cDBTagAt) == 0 {
+			err = newMigration(*cDBTagFile).Tag(*cDBTagName)
+		} else if arr := strings.SplitN(*cDBTagAt, ":", 3); len(arr) < 2 {
+			err = fmt.Errorf("error - changeset must be in the format author:id[:file]: %v", *cDBTagAt)
+		} else {
+			filename := ""
+			if len(arr) == 3 {
+				filename = arr[2]
+			}
+			err = newMigration(*cDBTagFile).TagChangeset(*cDBTagName, arr[0], arr[1], filename)
+		}
+	case cDBTags.FullCommand():
+		_, err = newMigration("").Tags()
+	case cDBRollback.FullCommand():
+		err = newDryRun(*cDBRollbackFile, *cDBRollbackSQL).Rollback(*cDBRollbackName)
+	case cDBRollbackDate.FullCommand():
+		var date time.Time
+		date, err = parseDate(*cDBRollbackDateDate, time.Now())
+		if err == nil {
+			err = newDryRun(*cDBRollbackDateFile, *cDBRollbackDateSQL).RollbackToDate(date)
+		}
+	case cDBRollbackTo.FullCommand():
+		filename := *cDBRollbackToFilename
+		if len(filename) == 0 {
+			filename = filepath.Base(*cDBRollbackToFile)
+		}
+		arr := strings.SplitN(*cDBRollbackToChangeset, ":", 2)
+		if len(arr) != 2 {
+			err = fmt.Errorf("error - changeset must be in the format author:id: %v", *cDBRollbackToChangeset)
+		} else {
+			err = newDryRun(*cDBRollbackToFile, *cDBRollbackToSQL).RollbackToChangeset(arr[0], arr[1], filename)
+		}
+	case cDBConvert.FullCommand():
+		err = newMigration(*cDBConvertFile).Convert(sqldb)
+	case cDBStatus.FullCommand():
+		var s *rove.StatusReport
+		s, err = newMigration(*cDBStatusFile).Status()
+		if err == nil && !s.Current() {
+			err = errors.New("error - changesets are pending, changed, or missing from the file")
+		}
+	case cDBLockStatus.FullCommand():
+		_, err = newMigration("").LockStatus()
+	case cDBLockRelease.FullCommand():
+		err = newMigration("").ReleaseLock()
+	}
+
+	// If there is an error, return with an error code of 1.
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
+}
+
+// parseDate returns the date in local time from a date and time, a time of
+// day on the day of now, or an RFC 3339 timestamp.
+func parseDate(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			y, m, d := now.Date()
+			return time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), 0, time.Local), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("error - invalid date: %v", s)
+}
+
+// newChangelog returns the changelog for the adapter along with the database
+// connection.
+func newChangelog(adapter string) (rove.Changelog, *sqlx.DB, error) {
+	switch adapter {
+	case adapterPostgres:
+		return newPostgres()
+	case adapterSQLite:
+		return newSQLite()
+	}
+
+	return newMySQL()
+}
+
+// newMySQL returns a MySQL changelog.
+func newMySQL() (rove.Changelog, *sqlx.DB, error) {
+	// Create the MySQL connection information from environment variables.
+	conn, err := mysql.NewConnection(*cDBPrefix)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	// Overwrite the database variables if there are parameters set.
 	if len(*cDBHost) > 0 {
@@ -110,56 +305,73 @@ func main() {
 	// Create a new MySQL database object.
 	db, err := mysql.New(conn)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		return nil, nil, err
 	}
 
-	switch arg {
-	case cDBAll.FullCommand():
-		r := rove.NewFileMigration(db, *cDBAllFile)
-		r.Verbose = true
-		r.Checksum = csMode
-		err = r.Migrate(0)
-	case cDBUp.FullCommand():
-		r := rove.NewFileMigration(db, *cDBUpFile)
-		r.Verbose = true
-		r.Checksum = csMode
-		err = r.Migrate(*cDBUpCount)
-	case cDBReset.FullCommand():
-		r := rove.NewFileMigration(db, *cDBResetFile)
-		r.Verbose = true
-		r.Checksum = csMode
-		err = r.Reset(0)
-	case cDBDown.FullCommand():
-		r := rove.NewFileMigration(db, *cDBDownFile)
-		r.Verbose = true
-		r.Checksum = csMode
-		err = r.Reset(*cDBDownCount)
-	case cDBTag.FullCommand():
-		r := rove.NewFileMigration(db, *cDBTagFile)
-		r.Verbose = true
-		r.Checksum = csMode
-		err = r.Tag(*cDBTagName)
-	case cDBRollback.FullCommand():
-		r := rove.NewFileMigration(db, *cDBRollbackFile)
-		r.Verbose = true
-		r.Checksum = csMode
-		err = r.Rollback(*cDBRollbackName)
-	case cDBConvert.FullCommand():
-		r := rove.NewFileMigration(db, *cDBConvertFile)
-		r.Verbose = true
-		r.Checksum = csMode
-		err = r.Convert(db.DB)
-	case cDBStatus.FullCommand():
-		r := rove.NewFileMigration(db, "")
-		r.Verbose = true
-		r.Checksum = csMode
-		_, err = r.Status()
+	return db, db.DB, nil
+}
+
+// newPostgres returns a PostgreSQL changelog.
+func newPostgres() (rove.Changelog, *sqlx.DB, error) {
+	// Create the PostgreSQL connection information from environment variables.
+	conn, err := postgres.NewConnection(*cDBPrefix)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// If there is an error, return with an error code of 1.
+	// Overwrite the database variables if there are parameters set.
+	if len(*cDBHost) > 0 {
+		conn.Hostname = *cDBHost
+	}
+	if *cDBPort > 0 {
+		conn.Port = *cDBPort
+	}
+	if len(*cDBUsername) > 0 {
+		conn.Username = *cDBUsername
+	}
+	if len(*cDBPassword) > 0 {
+		conn.Password = *cDBPassword
+	}
+	if len(*cDBName) > 0 {
+		conn.Name = *cDBName
+	}
+	if len(*cDBSchema) > 0 {
+		conn.Schema = *cDBSchema
+	}
+	if len(*cDBParameter) > 0 {
+		conn.Parameter = *cDBParameter
+	}
+
+	// Create a new PostgreSQL database object.
+	db, err := postgres.New(conn)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		return nil, nil, err
 	}
+
+	return db, db.DB, nil
+}
+
+// newSQLite returns a SQLite changelog.
+func newSQLite() (rove.Changelog, *sqlx.DB, error) {
+	// Create the SQLite connection information from environment variables.
+	conn, err := sqlite.NewConnection(*cDBPrefix)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Overwrite the database variables if there are parameters set.
+	if len(*cDBName) > 0 {
+		conn.Name = *cDBName
+	}
+	if len(*cDBParameter) > 0 {
+		conn.Parameter = *cDBParameter
+	}
+
+	// Create a new SQLite database object.
+	db, err := sqlite.New(conn)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return db, db.DB, nil
 }
@@ -4,6 +4,7 @@ import (
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/josephspurrier/rove/pkg/adapter/mysql/testutil"
 
@@ -79,9 +80,9 @@ func TestMigrationReset(t *testing.T) {
 	assert.Nil(t, err)
 	os.Stdout = backupd
 
-	assert.Contains(t, string(out), "Applied: 3) josephspurrier:3 (success.sql) 25f77f488ead6c37bc98ca17b3b52d81 [tag='']")
-	assert.Contains(t, string(out), "Applied: 2) josephspurrier:2 (success.sql) 0da13ad4c9f930f7cef760f2fea09854 [tag='']")
-	assert.Contains(t, string(out), "Applied: 1) josephspurrier:1 (success.sql) a80573cbde97740723233be4ef760fe9 [tag='']")
+	assert.Contains(t, string(out), "Applied: 3) josephspurrier:3 (success.sql) 2:91fd243692bd871e16aaefa5758087b62734aa24096d5abc8a0695350f170514 [tag='']")
+	assert.Contains(t, string(out), "Applied: 2) josephspurrier:2 (success.sql) 2:3ceeebb23ba9f19d3e778ac2058fe2d7dbfc36545ca8a9a1890058ff977caa83 [tag='']")
+	assert.Contains(t, string(out), "Applied: 1) josephspurrier:1 (success.sql) 2:376c3c3715ff7b052c547ac80b2433f79abb3d045a364d0594a449d8334a4847 [tag='']")
 
 	testutil.TeardownDatabase(unique)
 }
@@ -120,8 +121,8 @@ func migrateUp(t *testing.T) (*sqlx.DB, string) {
 	assert.Nil(t, err)
 	os.Stdout = backupd
 
-	assert.Contains(t, string(out), "Applied: 1) josephspurrier:1 (success.sql) a80573cbde97740723233be4ef760fe9 [tag='']")
-	assert.Contains(t, string(out), "Applied: 2) josephspurrier:2 (success.sql) 0da13ad4c9f930f7cef760f2fea09854 [tag='']")
+	assert.Contains(t, string(out), "Applied: 1) josephspurrier:1 (success.sql) 2:376c3c3715ff7b052c547ac80b2433f79abb3d045a364d0594a449d8334a4847 [tag='']")
+	assert.Contains(t, string(out), "Applied: 2) josephspurrier:2 (success.sql) 2:3ceeebb23ba9f19d3e778ac2058fe2d7dbfc36545ca8a9a1890058ff977caa83 [tag='']")
 
 	return db, unique
 }
@@ -155,7 +156,183 @@ func TestMigrationDown(t *testing.T) {
 	assert.Nil(t, err)
 	os.Stdout = backupd
 
-	assert.Contains(t, string(out), "Applied: 2) josephspurrier:2 (success.sql) 0da13ad4c9f930f7cef760f2fea09854 [tag='']")
+	assert.Contains(t, string(out), "Applied: 2) josephspurrier:2 (success.sql) 2:3ceeebb23ba9f19d3e778ac2058fe2d7dbfc36545ca8a9a1890058ff977caa83 [tag='']")
 
 	testutil.TeardownDatabase(unique)
 }
+
+func TestMigrationSQLite(t *testing.T) {
+	f, err := ioutil.TempFile("", "rove")
+	assert.Nil(t, err)
+	f.Close()
+	defer os.Remove(f.Name())
+
+	out := runSQLite(t, f.Name(), "all", "testdata/sqlite.sql")
+	assert.Contains(t, out, "Applied: 1) josephspurrier:1 (sqlite.sql)")
+	assert.Contains(t, out, "Applied: 2) josephspurrier:2 (sqlite.sql)")
+	assert.Contains(t, out, "Applied: 3) josephspurrier:3 (sqlite.sql)")
+
+	out = runSQLite(t, f.Name(), "status", "testdata/sqlite.sql")
+	assert.Contains(t, out, "Changesets applied:")
+	assert.Contains(t, out, "No changesets pending.")
+}
+
+func TestDryRunSQLite(t *testing.T) {
+	f, err := ioutil.TempFile("", "rove")
+	assert.Nil(t, err)
+	f.Close()
+	defer os.Remove(f.Name())
+
+	out := runSQLite(t, f.Name(), "all", "testdata/sqlite.sql", "--sql")
+	assert.Contains(t, out, "-- Changeset josephspurrier:1 (sqlite.sql)")
+	assert.Contains(t, out, "CREATE TABLE user_status")
+	assert.NotContains(t, out, "Applied:")
+
+	// Ensure nothing was applied.
+	out = runSQLite(t, f.Name(), "all", "testdata/sqlite.sql")
+	assert.Contains(t, out, "Applied: 1) josephspurrier:1 (sqlite.sql)")
+}
+
+func TestPropertiesSQLite(t *testing.T) {
+	f, err := ioutil.TempFile("", "rove")
+	assert.Nil(t, err)
+	f.Close()
+	defer os.Remove(f.Name())
+
+	m, err := ioutil.TempFile("", "rove*.sql")
+	assert.Nil(t, err)
+	defer os.Remove(m.Name())
+	_, err = m.WriteString("--changeset josephspurrier:1\nCREATE TABLE ${table} (id INTEGER);\n")
+	assert.Nil(t, err)
+	m.Close()
+
+	out := runSQLite(t, f.Name(), "all", m.Name(), "--sql", "-D", "table=user_status")
+	assert.Contains(t, out, "CREATE TABLE user_status (id INTEGER);")
+}
+
+func TestRollbackToSQLite(t *testing.T) {
+	f, err := ioutil.TempFile("", "rove")
+	assert.Nil(t, err)
+	f.Close()
+	defer os.Remove(f.Name())
+
+	runSQLite(t, f.Name(), "all", "testdata/sqlite.sql")
+	out := runSQLite(t, f.Name(), "rollback-to", "josephspurrier:1", "testdata/sqlite.sql")
+	assert.Contains(t, out, "Found changeset josephspurrier:1 (sqlite.sql), will rollback (2) changeset(s):")
+	assert.Contains(t, out, "Applied: 2) josephspurrier:2 (sqlite.sql)")
+
+	runSQLite(t, f.Name(), "all", "testdata/sqlite.sql")
+	out = runSQLite(t, f.Name(), "rollback-to-date", "2000-01-01", "testdata/sqlite.sql", "--sql")
+	assert.Contains(t, out, "-- Rollback josephspurrier:1 (sqlite.sql)")
+	assert.NotContains(t, out, "Applied:")
+
+	out = runSQLite(t, f.Name(), "rollback-to-date", "2000-01-01", "testdata/sqlite.sql")
+	assert.Contains(t, out, "will rollback (3) changeset(s):")
+}
+
+func TestUpToSQLite(t *testing.T) {
+	f, err := ioutil.TempFile("", "rove")
+	assert.Nil(t, err)
+	f.Close()
+	defer os.Remove(f.Name())
+
+	out := runSQLite(t, f.Name(), "up-to", "josephspurrier:2", "testdata/sqlite.sql")
+	assert.Contains(t, out, "Changesets applied (target: josephspurrier:2):")
+	assert.Contains(t, out, "Applied: 2) josephspurrier:2 (sqlite.sql)")
+	assert.NotContains(t, out, "josephspurrier:3")
+}
+
+func TestSyncSQLite(t *testing.T) {
+	f, err := ioutil.TempFile("", "rove")
+	assert.Nil(t, err)
+	f.Close()
+	defer os.Remove(f.Name())
+
+	out := runSQLite(t, f.Name(), "sync", "testdata/sqlite.sql", "--to", "josephspurrier:1", "--sql")
+	assert.Contains(t, out, "'MARK_RAN'")
+	assert.NotContains(t, out, "CREATE TABLE user_status")
+
+	out = runSQLite(t, f.Name(), "sync", "testdata/sqlite.sql", "--to", "josephspurrier:1")
+	assert.Contains(t, out, "Changesets marked as ran (target: josephspurrier:1):")
+	assert.Contains(t, out, "Marked as ran: 1) josephspurrier:1 (sqlite.sql)")
+	assert.NotContains(t, out, "josephspurrier:2")
+}
+
+func TestTagsSQLite(t *testing.T) {
+	f, err := ioutil.TempFile("", "rove")
+	assert.Nil(t, err)
+	f.Close()
+	defer os.Remove(f.Name())
+
+	runSQLite(t, f.Name(), "all", "testdata/sqlite.sql")
+	out := runSQLite(t, f.Name(), "tag", "v1", "testdata/sqlite.sql", "--at", "josephspurrier:1")
+	assert.Contains(t, out, "Tag applied: v1 on josephspurrier:1")
+	out = runSQLite(t, f.Name(), "tag", "v2", "testdata/sqlite.sql", "--at", "josephspurrier:2:sqlite.sql")
+	assert.Contains(t, out, "Tag applied: v2 on josephspurrier:2")
+	out = runSQLite(t, f.Name(), "tag", "v3", "testdata/sqlite.sql")
+	assert.Contains(t, out, "Tag applied: v3 on josephspurrier:3")
+
+	out = runSQLite(t, f.Name(), "tags")
+	assert.Contains(t, out, "v1: 1) josephspurrier:1 (sqlite.sql) applied ")
+	assert.Contains(t, out, "v2: 2) josephspurrier:2 (sqlite.sql) applied ")
+	assert.Contains(t, out, "v3: 3) josephspurrier:3 (sqlite.sql) applied ")
+}
+
+func TestParseDate(t *testing.T) {
+	now := time.Date(2020, 5, 6, 7, 8, 9, 0, time.Local)
+	for _, v := range []struct {
+		date     string
+		expected time.Time
+	}{
+		{"2020-01-02T03:04:05Z", time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{"2020-01-02 03:04:05", time.Date(2020, 1, 2, 3, 4, 5, 0, time.Local)},
+		{"2020-01-02 03:04", time.Date(2020, 1, 2, 3, 4, 0, 0, time.Local)},
+		{"2020-01-02", time.Date(2020, 1, 2, 0, 0, 0, 0, time.Local)},
+		{"14:05", time.Date(2020, 5, 6, 14, 5, 0, 0, time.Local)},
+		{"14:05:30", time.Date(2020, 5, 6, 14, 5, 30, 0, time.Local)},
+	} {
+		d, err := parseDate(v.date, now)
+		assert.Nil(t, err, v.date)
+		assert.True(t, v.expected.Equal(d), v.date)
+	}
+
+	_, err := parseDate("yesterday", now)
+	assert.NotNil(t, err)
+}
+
+func TestLockSQLite(t *testing.T) {
+	f, err := ioutil.TempFile("", "rove")
+	assert.Nil(t, err)
+	f.Close()
+	defer os.Remove(f.Name())
+
+	out := runSQLite(t, f.Name(), "lock", "status")
+	assert.Contains(t, out, "Changelog is not locked.")
+
+	out = runSQLite(t, f.Name(), "lock", "release")
+	assert.Contains(t, out, "Changelog lock released.")
+}
+
+// runSQLite will run the application against a SQLite database file and
+// return the output.
+func runSQLite(t *testing.T, filename string, args ...string) string {
+	// Set the arguments.
+	os.Args = append([]string{"rove"}, args...)
+	os.Args = append(os.Args, "--adapter", "sqlite", "--name", filename)
+
+	// Redirect stdout.
+	backupd := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	// Call the application.
+	main()
+
+	// Get the output.
+	w.Close()
+	out, err := ioutil.ReadAll(r)
+	assert.Nil(t, err)
+	os.Stdout = backupd
+
+	return string(out)
+}
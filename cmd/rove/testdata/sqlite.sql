@@ -0,0 +1,35 @@
+--changeset josephspurrier:1
+--description Create the user status table.
+CREATE TABLE user_status (
+    id INTEGER NOT NULL PRIMARY KEY,
+    
+    status VARCHAR(25) NOT NULL,
+    
+    created_at TIMESTAMP NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL DEFAULT NULL
+);
+--rollback DROP TABLE user_status;
+
+--changeset josephspurrier:2
+INSERT INTO user_status (id, status, created_at, updated_at) VALUES
+(1, 'active',   CURRENT_TIMESTAMP,  CURRENT_TIMESTAMP),
+(2, 'inactive', CURRENT_TIMESTAMP,  CURRENT_TIMESTAMP);
+--rollback DELETE FROM user_status;
+
+--changeset josephspurrier:3
+CREATE TABLE user (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    
+    first_name VARCHAR(50) NOT NULL,
+    last_name VARCHAR(50) NOT NULL,
+    email VARCHAR(100) NOT NULL UNIQUE,
+    password CHAR(60) NOT NULL,
+    
+    status_id INTEGER NOT NULL DEFAULT 1 REFERENCES user_status (id),
+    
+    created_at TIMESTAMP NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL DEFAULT NULL
+);
+--rollback DROP TABLE user;
@@ -0,0 +1,203 @@
+package rove
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/josephspurrier/rove/pkg/changeset"
+)
+
+// writeApply will write the changeset and the changelog record to the dry run
+// writer instead of applying the changeset. The offset is the number of
+// records already written so the order executed follows the changelog.
+func (r *Rove) writeApply(cs changeset.Record, offset int) error {
+	checksum, err := r.checksum(cs)
+	if err != nil {
+		return err
+	}
+
+	// Count the number of rows.
+	count, err := r.count()
+	if err != nil {
+		return fmt.Errorf("error on counting changelog rows: %v", err)
+	}
+
+	record := cs
+	record.OrderExecuted = count + offset + 1
+	record.DateExecuted = time.Now()
+	record.Checksum = checksum
+	record.ExecType = changeset.ExecTypeExecuted
+
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "-- Changeset %v:%v (%v) checksum %v\n", cs.Author, cs.ID,
+		cs.Filename, record.Checksum)
+	writeChanges(buf, cs, false)
+
+	if c, ok := r.db.(ChangelogSQL); ok {
+		writeSQL(buf, c.InsertSQL(record))
+	}
+	fmt.Fprintln(buf)
+
+	return r.writeDryRun(buf)
+}
+
+// writeRerun will write the changeset and the update of the changelog record
+// to the dry run writer instead of applying the changeset again.
+func (r *Rove) writeRerun(cs changeset.Record, applied changeset.Record) error {
+	record, err := r.rerunRecord(cs, applied)
+	if err != nil {
+		return err
+	}
+
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "-- Changeset %v:%v (%v) checksum %v\n", cs.Author, cs.ID,
+		cs.Filename, record.Checksum)
+	fmt.Fprintf(buf, "-- Changeset is applied again (rerun %v).\n", record.Reruns)
+	writeChanges(buf, cs, false)
+
+	if c, ok := r.db.(ChangelogSQL); ok {
+		writeSQL(buf, c.UpdateSQL(record))
+	}
+	fmt.Fprintln(buf)
+
+	return r.writeDryRun(buf)
+}
+
+// writeRollback will write the rollback of the changeset and the removal of
+// the changelog record to the dry run writer instead of running the rollback.
+func (r *Rove) writeRollback(cs changeset.Record, record changeset.Record) error {
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "-- Rollback %v:%v (%v) checksum %v\n", record.Author,
+		record.ID, record.Filename, record.Checksum)
+	if record.ExecType != changeset.ExecTypeMarkRan {
+		writeChanges(buf, cs, true)
+	}
+
+	if c, ok := r.db.(ChangelogSQL); ok {
+		writeSQL(buf, c.DeleteSQL(record.ID, record.Author, record.Filename))
+	}
+	fmt.Fprintln(buf)
+
+	return r.writeDryRun(buf)
+}
+
+// writeUpdate will write the update of the changelog record to the dry run
+// writer with a comment.
+func (r *Rove) writeUpdate(record changeset.Record, comment string) error {
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "-- Changeset %v:%v (%v) %v\n", record.Author, record.ID,
+		record.Filename, comment)
+
+	if c, ok := r.db.(ChangelogSQL); ok {
+		writeSQL(buf, c.UpdateSQL(record))
+	}
+	fmt.Fprintln(buf)
+
+	return r.writeDryRun(buf)
+}
+
+// initialize will create the changelog. A dry run doesn't change the database
+// so the changelog is not created and a missing changelog is treated as empty.
+func (r *Rove) initialize() error {
+	if r.DryRun == nil {
+		r.missingChangelog = false
+		err := r.db.Initialize()
+		if err != nil {
+			return fmt.Errorf("error on changelog creation: %v", err)
+		}
+		return nil
+	}
+
+	_, err := r.dryRunMissing()
+	return err
+}
+
+// dryRunMissing returns true on a dry run when the changelog doesn't exist so
+// there are no changesets applied.
+func (r *Rove) dryRunMissing() (bool, error) {
+	r.missingChangelog = false
+	if r.DryRun == nil {
+		return false, nil
+	}
+
+	exists, err := r.changelogExists()
+	if err != nil {
+		return false, err
+	}
+	r.missingChangelog = !exists
+
+	return r.missingChangelog, nil
+}
+
+// changelogExists returns true if the changelog exists or if the adapter
+// can't check for it.
+func (r *Rove) changelogExists() (bool, error) {
+	c, ok := r.db.(ChangelogExists)
+	if !ok {
+		return true, nil
+	}
+
+	exists, err := c.ChangelogExists()
+	if err != nil {
+		return false, fmt.Errorf("error on checking changelog: %v", err)
+	}
+
+	return exists, nil
+}
+
+// changesetApplied returns the record of the changeset from the changelog or
+// nil if the changeset is not in the changelog.
+func (r *Rove) changesetApplied(cs changeset.Record) (*changeset.Record, error) {
+	if r.missingChangelog {
+		return nil, nil
+	}
+
+	return r.db.ChangesetApplied(cs.ID, cs.Author, cs.Filename)
+}
+
+// count returns the number of changesets in the changelog.
+func (r *Rove) count() (int, error) {
+	if r.missingChangelog {
+		return 0, nil
+	}
+
+	return r.db.Count()
+}
+
+// writeDryRun will write the buffer to the dry run writer.
+func (r *Rove) writeDryRun(buf *bytes.Buffer) error {
+	_, err := r.DryRun.Write(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("error on writing dry run - %v", err)
+	}
+
+	return nil
+}
+
+// writeChanges will write the changes or the rollback of the changeset. A Go
+// changeset can't be written so only a comment is written.
+func writeChanges(buf *bytes.Buffer, cs changeset.Record, rollback bool) {
+	if isGoChangeset(cs) {
+		fmt.Fprintln(buf, "-- Changeset is a Go function that is not run on a dry run.")
+	} else if rollback {
+		writeSQL(buf, cs.Rollbacks())
+	} else {
+		writeSQL(buf, cs.Changes())
+	}
+}
+
+// writeSQL will write the query terminated with a semicolon.
+func writeSQL(buf *bytes.Buffer, query string) {
+	query = strings.TrimSpace(query)
+	if len(query) == 0 {
+		return
+	}
+
+	if !strings.HasSuffix(query, ";") {
+		query += ";"
+	}
+
+	fmt.Fprintln(buf, query)
+}
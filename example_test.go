@@ -36,7 +36,7 @@ INSERT INTO user_status (id, status, created_at, updated_at, deleted) VALUES
 		Password:  "password",
 		Name:      "main",
 		Port:      3306,
-		Parameter: "collation=utf8mb4_unicode_ci&parseTime=true&multiStatements=true",
+		Parameter: "collation=utf8mb4_unicode_ci&parseTime=true",
 	})
 	if err != nil {
 		log.Fatalln(err)
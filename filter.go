@@ -0,0 +1,43 @@
+package rove
+
+import (
+	"fmt"
+
+	"github.com/josephspurrier/rove/pkg/changeset"
+	"github.com/josephspurrier/rove/pkg/expression"
+)
+
+// filter will return only the changesets that match the contexts and labels
+// expressions. A changeset without contexts or labels always matches.
+func (r *Rove) filter(arr []changeset.Record) ([]changeset.Record, error) {
+	contexts, err := expression.Parse(r.Contexts)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing contexts - %v", err)
+	}
+
+	labels, err := expression.Parse(r.Labels)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing labels - %v", err)
+	}
+
+	out := make([]changeset.Record, 0)
+	for _, cs := range arr {
+		if !matches(contexts, cs.Contexts) || !matches(labels, cs.Labels) {
+			continue
+		}
+		out = append(out, cs)
+	}
+
+	return out, nil
+}
+
+// matches returns true if the comma separated list is empty or it matches the
+// expression.
+func matches(e *expression.Expression, list string) bool {
+	names := expression.Split(list)
+	if len(names) == 0 {
+		return true
+	}
+
+	return e.Match(names)
+}
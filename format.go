@@ -0,0 +1,226 @@
+package rove
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/josephspurrier/rove/pkg/changeset"
+
+	"gopkg.in/yaml.v2"
+)
+
+// changelogDocument is a YAML or JSON migration file. The logical file path
+// is the filename stored with the changesets instead of the name of the file so
+// a migration file can be converted from SQL without rerunning the changesets.
+type changelogDocument struct {
+	LogicalFilePath string           `yaml:"logicalFilePath" json:"logicalFilePath"`
+	Changelog       []changelogEntry `yaml:"changelog" json:"changelog"`
+}
+
+// changelogEntry is one element of a YAML or JSON migration file. Only one of
+// the property, include, includeAll, or changeset can be set.
+type changelogEntry struct {
+	Property   string          `yaml:"property" json:"property"`
+	Include    string          `yaml:"include" json:"include"`
+	IncludeAll string          `yaml:"includeAll" json:"includeAll"`
+	Recursive  bool            `yaml:"recursive" json:"recursive"`
+	Changeset  *changesetEntry `yaml:"changeset" json:"changeset"`
+}
+
+// changesetEntry is a changeset in a YAML or JSON migration file. The
+// attributes are the same as the attributes in the header of a SQL changeset
+// and the preconditions are the same as the SQL precondition lines without the
+// leading dashes.
+type changesetEntry struct {
+	ID            string                 `yaml:"id" json:"id"`
+	Author        string                 `yaml:"author" json:"author"`
+	Description   string                 `yaml:"description" json:"description"`
+	TagDatabase   string                 `yaml:"tagDatabase" json:"tagDatabase"`
+	Attributes    map[string]interface{} `yaml:"attributes" json:"attributes"`
+	Preconditions []string               `yaml:"preconditions" json:"preconditions"`
+	ValidCheckSum []string               `yaml:"validCheckSum" json:"validCheckSum"`
+	SQL           string                 `yaml:"sql" json:"sql"`
+	Rollback      string                 `yaml:"rollback" json:"rollback"`
+}
+
+// decodeYAML will decode a YAML migration file. Unknown fields are an error.
+func decodeYAML(r io.Reader, doc *changelogDocument) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	return yaml.UnmarshalStrict(b, doc)
+}
+
+// decodeJSON will decode a JSON migration file. Unknown fields are an error.
+func decodeJSON(r io.Reader, doc *changelogDocument) error {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	return dec.Decode(doc)
+}
+
+// parseStructured will convert a YAML or JSON migration into an ordered array
+// along with the position of each changeset. The changesets are the same as
+// the changesets from the equivalent SQL migration so the checksums match. The
+// positions don't have a line number so the snippet is the index of the entry.
+func (p *parser) parseStructured(r io.Reader, filename string,
+	decode func(io.Reader, *changelogDocument) error) ([]changeset.Record, []position, error) {
+	doc := new(changelogDocument)
+	err := decode(r, doc)
+	if err != nil {
+		return nil, nil, position{file: filename}.parseError(err)
+	}
+
+	name := path.Base(filename)
+	if len(doc.LogicalFilePath) > 0 {
+		name = doc.LogicalFilePath
+	}
+
+	// Array of changesets.
+	arr := make([]changeset.Record, 0)
+	positions := make([]position, 0)
+
+	for i, v := range doc.Changelog {
+		pos := position{
+			file:    filename,
+			snippet: fmt.Sprintf("changelog[%v]", i),
+		}
+
+		set := 0
+		for _, b := range []bool{len(v.Property) > 0, len(v.Include) > 0,
+			len(v.IncludeAll) > 0, v.Changeset != nil} {
+			if b {
+				set++
+			}
+		}
+		if set != 1 || (v.Recursive && len(v.IncludeAll) == 0) {
+			return nil, nil, pos.parseError(ErrInvalidFormat)
+		}
+
+		switch {
+		case len(v.Property) > 0:
+			err = p.addProperty(v.Property)
+			if err != nil {
+				return nil, nil, pos.parseError(err)
+			}
+		case len(v.Include) > 0, len(v.IncludeAll) > 0:
+			var files []string
+			if len(v.Include) > 0 {
+				files, err = p.glob(filename, v.Include)
+			} else {
+				files, err = p.dir(filename, v.IncludeAll, v.Recursive)
+			}
+			if err != nil {
+				return nil, nil, pos.parseError(err)
+			}
+
+			cs, ps, err := p.include(pos, files)
+			if err != nil {
+				return nil, nil, err
+			}
+			arr = append(arr, cs...)
+			positions = append(positions, ps...)
+		default:
+			cs, err := v.Changeset.record()
+			if err != nil {
+				return nil, nil, pos.parseError(err)
+			}
+			cs.SetFileInfo(name, appVersion)
+			arr = append(arr, *cs)
+			positions = append(positions, pos)
+		}
+	}
+
+	return arr, positions, nil
+}
+
+// record returns the changeset in the same way as the SQL parser. The lines
+// are trimmed and the blank lines and comments are skipped in the changes.
+func (e *changesetEntry) record() (*changeset.Record, error) {
+	// Build the header so the attributes and defaults match a SQL changeset.
+	header := []string{e.Author + ":" + e.ID}
+	keys := make([]string, 0, len(e.Attributes))
+	for k := range e.Attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		header = append(header, fmt.Sprintf("%v:%v", k, e.Attributes[k]))
+	}
+
+	for _, v := range header {
+		if len(strings.Fields(v)) != 1 {
+			return nil, changeset.ErrInvalidHeader
+		}
+	}
+	if len(e.Author) == 0 || len(e.ID) == 0 {
+		return nil, changeset.ErrInvalidHeader
+	}
+
+	cs := new(changeset.Record)
+	err := cs.ParseHeader(strings.Join(header, " "))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range lines(e.Description) {
+		cs.AddDescription(line)
+	}
+
+	if len(e.TagDatabase) > 0 {
+		err = setTag(cs, e.TagDatabase)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, v := range e.Preconditions {
+		line := "--" + strings.TrimSpace(v)
+		if !strings.HasPrefix(line, elementPreconditions) &&
+			!strings.HasPrefix(line, elementPreconditionSQLCheck) &&
+			!strings.HasPrefix(line, elementPreconditionTable) &&
+			!strings.HasPrefix(line, elementPreconditionColumn) {
+			return nil, fmt.Errorf("%v - %v", changeset.ErrInvalidPrecondition, v)
+		}
+		err = parsePrecondition(cs, line)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, v := range e.ValidCheckSum {
+		cs.AddValidChecksum(v)
+	}
+
+	for _, line := range lines(e.SQL) {
+		if strings.HasPrefix(line, "--") {
+			continue
+		}
+		cs.AddChange(line)
+	}
+
+	for _, line := range lines(e.Rollback) {
+		cs.AddRollback(line)
+	}
+
+	return cs, nil
+}
+
+// lines returns the lines of the text without leading or trailing spaces and
+// without the blank lines.
+func lines(text string) []string {
+	arr := make([]string, 0)
+	for _, v := range strings.Split(text, "\n") {
+		line := strings.TrimSpace(v)
+		if len(line) > 0 {
+			arr = append(arr, line)
+		}
+	}
+
+	return arr
+}
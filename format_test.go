@@ -0,0 +1,113 @@
+package rove_test
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/josephspurrier/rove"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLiteFormats(t *testing.T) {
+	s := newSQLite(t)
+
+	// Run the SQL migration.
+	r := rove.NewFileMigration(s, "testdata/sqlite/success.sql")
+	err := r.Migrate(0)
+	assert.Nil(t, err)
+
+	// Ensure the YAML and JSON migration matches the SQL migration.
+	r = rove.NewFileMigration(s, "testdata/sqlite/success.yaml")
+	st, err := r.Status()
+	assert.Nil(t, err)
+	if assert.NotNil(t, st) {
+		assert.True(t, st.Current())
+		assert.Equal(t, "3", st.Last.ID)
+		assert.Equal(t, "success.sql", st.Last.Filename)
+	}
+
+	// Remove all migrations with the YAML migration.
+	err = r.Reset(0)
+	assert.Nil(t, err)
+
+	count := 1
+	err = s.DB.Get(&count, `SELECT COUNT(*) FROM sqlite_master WHERE name IN ('user', 'user_status')`)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestSQLiteFormatsInclude(t *testing.T) {
+	s := newSQLite(t)
+
+	fsys := fstest.MapFS{
+		"changelog.yml": &fstest.MapFile{Data: []byte(`changelog:
+  - property: table=user_status
+  - includeAll: changes
+  - changeset:
+      id: 3
+      author: josephspurrier
+      attributes:
+        runOnChange: true
+      preconditions:
+        - precondition-table-exists tableName:${table}
+      sql: INSERT INTO ${table} (id, status) VALUES (2, 'inactive');
+      rollback: DELETE FROM ${table} WHERE id = 2;`)},
+		"changes/001.sql": &fstest.MapFile{Data: []byte(`--changeset josephspurrier:1
+CREATE TABLE ${table} (id INTEGER NOT NULL PRIMARY KEY, status VARCHAR(25) NOT NULL);
+--rollback DROP TABLE ${table};`)},
+		"changes/002.json": &fstest.MapFile{Data: []byte(`{"changelog": [{"changeset": {
+  "id": "2", "author": "josephspurrier",
+  "sql": "INSERT INTO ${table} (id, status) VALUES (1, 'active');",
+  "rollback": "DELETE FROM ${table} WHERE id = 1;"}}]}`)},
+	}
+
+	// Run the migration.
+	r := rove.NewFSMigration(s, fsys, "changelog.yml")
+	err := r.Migrate(0)
+	assert.Nil(t, err)
+
+	count := 0
+	err = s.DB.Get(&count, `SELECT COUNT(*) FROM user_status`)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, count)
+
+	st, err := r.Status()
+	assert.Nil(t, err)
+	if assert.NotNil(t, st) {
+		assert.True(t, st.Current())
+		assert.Equal(t, "changelog.yml", st.Last.Filename)
+	}
+
+	// Ensure the errors have the location of the entry.
+	for _, v := range []struct {
+		changelog string
+		err       error
+		snippet   string
+	}{
+		{"changelog:\n  - changeset: {id: 1, author: a}\n  - include: missing/*.sql", rove.ErrIncludeNotFound, "changelog[1]"},
+		{"changelog:\n  - property: table", rove.ErrInvalidProperty, "changelog[0]"},
+		{"changelog:\n  - include: a.sql\n    property: a=b", rove.ErrInvalidFormat, "changelog[0]"},
+		{"changelog:\n  - {}", rove.ErrInvalidFormat, "changelog[0]"},
+		{"changelog:\n  - include: changelog.yml", rove.ErrIncludeCycle, "changelog[0]"},
+		{"changelog:\n  - changeset: {id: 1}", nil, "changelog[0]"},
+		{"changelog:\n  - changeset: {id: 1, author: a, attributes: {context: a b}}", nil, "changelog[0]"},
+		{"changelog:\n  - changeset: {id: 1, author: a, preconditions: [table-exists]}", nil, "changelog[0]"},
+		{"changelog:\n  - changeset: {id: 1, author: a}\n  - changeset: {id: 1, author: a}", nil, "changelog[1]"},
+		{"changelog:\n  - changeset: {id: 1, author: a, name: b}", nil, ""},
+	} {
+		fsys["changelog.yml"] = &fstest.MapFile{Data: []byte(v.changelog)}
+		err := rove.NewFSMigration(newSQLite(t), fsys, "changelog.yml").Migrate(0)
+		if v.err != nil {
+			assert.True(t, errors.Is(err, v.err), v.changelog)
+		}
+
+		var pe *rove.ParseError
+		if assert.True(t, errors.As(err, &pe), v.changelog) {
+			assert.Equal(t, "changelog.yml", pe.File, v.changelog)
+			assert.Equal(t, 0, pe.Line, v.changelog)
+			assert.Equal(t, v.snippet, pe.Snippet, v.changelog)
+		}
+	}
+}
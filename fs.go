@@ -0,0 +1,17 @@
+package rove
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// osFS is a file system that opens files from the operating system. Unlike
+// os.DirFS, it allows absolute paths and paths that start with ".." so
+// includes can reference any file.
+type osFS struct{}
+
+// Open will open the file from the operating system.
+func (osFS) Open(name string) (fs.File, error) {
+	return os.Open(filepath.FromSlash(name))
+}
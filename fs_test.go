@@ -0,0 +1,159 @@
+package rove_test
+
+import (
+	"embed"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/josephspurrier/rove"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//go:embed testdata/sqlite/*.sql
+var testdata embed.FS
+
+func TestSQLiteEmbed(t *testing.T) {
+	s := newSQLite(t)
+
+	// Set up rove.
+	r := rove.NewFSMigration(s, testdata, "testdata/sqlite/success.sql")
+	r.Verbose = true
+
+	// Run migration.
+	err := r.Migrate(0)
+	assert.Nil(t, err)
+
+	// Get the status.
+	st, err := r.Status()
+	assert.Nil(t, err)
+	assert.True(t, st.Current())
+	assert.Equal(t, "3", st.Last.ID)
+	assert.Equal(t, "success.sql", st.Last.Filename)
+
+	// Remove all migrations.
+	err = r.Reset(0)
+	assert.Nil(t, err)
+}
+
+func TestSQLiteFSInclude(t *testing.T) {
+	s := newSQLite(t)
+
+	fsys := fstest.MapFS{
+		"migrations/changelog.sql": &fstest.MapFile{Data: []byte(`--include tables/user_status.sql
+--include ../seed/data.sql`)},
+		"migrations/tables/user_status.sql": &fstest.MapFile{Data: []byte(`--changeset josephspurrier:1
+CREATE TABLE user_status (id INTEGER NOT NULL PRIMARY KEY, status VARCHAR(25) NOT NULL);
+--rollback DROP TABLE user_status;`)},
+		"seed/data.sql": &fstest.MapFile{Data: []byte(`--changeset josephspurrier:1
+INSERT INTO user_status (id, status) VALUES (1, 'active');
+--rollback DELETE FROM user_status;`)},
+	}
+
+	// Set up rove.
+	r := rove.NewFSMigration(s, fsys, "migrations/changelog.sql")
+	r.Verbose = true
+
+	// Run migration.
+	err := r.Migrate(0)
+	assert.Nil(t, err)
+
+	count := 0
+	err = s.DB.Get(&count, `SELECT COUNT(*) FROM user_status`)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+
+	// Ensure a missing include returns an error.
+	fsys["migrations/changelog.sql"] = &fstest.MapFile{Data: []byte(`--include missing.sql`)}
+	err = r.Migrate(0)
+	assert.NotNil(t, err)
+}
+
+func TestSQLiteFSIncludeAll(t *testing.T) {
+	changeset := func(id string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte("--changeset josephspurrier:" + id + "\nSELECT " + id + ";")}
+	}
+
+	fsys := fstest.MapFS{
+		"changelog.sql":                     &fstest.MapFile{Data: []byte("--includeAll changes\n--include seed/*.sql")},
+		"changes/002_user.sql":              changeset("2"),
+		"changes/001_user_status.sql":       changeset("1"),
+		"changes/README.md":                 &fstest.MapFile{Data: []byte("Not a migration.")},
+		"changes/archive/000_old.sql":       changeset("0"),
+		"changes/archive/deep/000_deep.sql": changeset("00"),
+		"seed/b.sql":                        changeset("4"),
+		"seed/a.sql":                        changeset("3"),
+		"seed/a.txt":                        changeset("5"),
+	}
+
+	// Ensure the files are included in lexical order.
+	order := func() []string {
+		s := newSQLite(t)
+		assert.Nil(t, s.Initialize())
+		r := rove.NewFSMigration(s, fsys, "changelog.sql")
+		st, err := r.Status()
+		assert.Nil(t, err)
+		arr := make([]string, 0)
+		if st != nil {
+			for _, v := range st.Pending {
+				arr = append(arr, v.Filename+":"+v.ID)
+			}
+		}
+		return arr
+	}
+
+	assert.Equal(t, []string{"001_user_status.sql:1", "002_user.sql:2",
+		"a.sql:3", "b.sql:4"}, order())
+
+	// Ensure the subdirectories are included when recursive.
+	fsys["changelog.sql"] = &fstest.MapFile{Data: []byte("--includeAll changes recursive:true")}
+	assert.Equal(t, []string{"001_user_status.sql:1", "002_user.sql:2",
+		"000_old.sql:0", "000_deep.sql:00"}, order())
+
+	// Ensure the errors have the location of the include.
+	for _, v := range []struct {
+		changelog string
+		err       error
+	}{
+		{"--includeAll changes recursive:yes", rove.ErrInvalidInclude},
+		{"--includeAll changes depth:1", rove.ErrInvalidInclude},
+		{"--include missing/*.sql", rove.ErrIncludeNotFound},
+		{"--include changelog.sql", rove.ErrIncludeCycle},
+		{"--include *.sql", rove.ErrIncludeCycle},
+	} {
+		fsys["changelog.sql"] = &fstest.MapFile{Data: []byte(v.changelog)}
+		r := rove.NewFSMigration(newSQLite(t), fsys, "changelog.sql")
+		err := r.Migrate(0)
+		assert.True(t, errors.Is(err, v.err), v.changelog)
+
+		var pe *rove.ParseError
+		if assert.True(t, errors.As(err, &pe), v.changelog) {
+			assert.Equal(t, "changelog.sql", pe.File, v.changelog)
+			assert.Equal(t, 1, pe.Line, v.changelog)
+		}
+	}
+
+	// Ensure a cycle through another file is detected.
+	fsys["changelog.sql"] = &fstest.MapFile{Data: []byte("--include cycle/a.sql")}
+	fsys["cycle/a.sql"] = &fstest.MapFile{Data: []byte("--include b.sql")}
+	fsys["cycle/b.sql"] = &fstest.MapFile{Data: []byte("--changeset josephspurrier:1\nSELECT 1;\n--include ../cycle/a.sql")}
+	r := rove.NewFSMigration(newSQLite(t), fsys, "changelog.sql")
+	err := r.Migrate(0)
+	assert.True(t, errors.Is(err, rove.ErrIncludeCycle))
+	assert.Contains(t, err.Error(), "cycle/b.sql:3:11: include cycle: changelog.sql -> cycle/a.sql -> cycle/b.sql -> cycle/a.sql")
+}
+
+func TestSQLiteIncludeGlob(t *testing.T) {
+	s := newSQLite(t)
+	assert.Nil(t, s.Initialize())
+
+	// Ensure a glob works on the file system.
+	r := rove.NewChangesetMigration(s, "--include testdata/sqlite/[cs]*.sql")
+	st, err := r.Status()
+	assert.Nil(t, err)
+	if assert.NotNil(t, st) {
+		assert.Equal(t, "contexts.sql", st.Pending[0].Filename)
+		assert.Equal(t, "success.sql", st.Pending[len(st.Pending)-1].Filename)
+	}
+}
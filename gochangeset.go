@@ -0,0 +1,173 @@
+package rove
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/josephspurrier/rove/pkg/changeset"
+)
+
+const (
+	// goFilename is the filename of the Go changesets in the changelog so a
+	// Go changeset can be moved between migration files without running again.
+	// A Go changeset is marked with GoChangeset instead of by the filename so
+	// a migration file named go is still SQL.
+	goFilename = "go"
+)
+
+var (
+	// ErrGoChangesetNotFound is when a go placeholder in a migration file
+	// doesn't have a registered Go changeset.
+	ErrGoChangesetNotFound = errors.New("go changeset is not registered")
+	// ErrInvalidGoChangeset is when a go placeholder in a migration file is
+	// followed by SQL or a rollback.
+	ErrInvalidGoChangeset = errors.New("go changeset cannot have sql or a rollback")
+)
+
+// GoFunc is a change written in Go. It runs in the same transaction as the
+// changelog operation. If the transaction satisfies SQLTransaction, the
+// database transaction is available for queries.
+type GoFunc func(ctx context.Context, tx Transaction) error
+
+// goChangeset is a changeset that runs Go functions instead of SQL.
+type goChangeset struct {
+	author  string
+	id      string
+	version string
+	up      GoFunc
+	down    GoFunc
+}
+
+// RegisterGoChangeset will add a changeset that runs the up function when it's
+// applied and the down function when it's rolled back. The down function can
+// be nil if there is no rollback. The changeset runs in the place of a
+// placeholder like --go author:id in the migration file or after the other
+// changesets in the order they are registered if there is no placeholder. The
+// checksum is of the version so change the version to change the checksum. If
+// the changeset is already registered or the author, id, version, or up
+// function is missing, it panics.
+func (r *Rove) RegisterGoChangeset(author, id, version string, up, down GoFunc) {
+	if len(author) == 0 || len(id) == 0 || len(version) == 0 {
+		panic("rove: go changeset requires an author, id, and version")
+	}
+	if up == nil {
+		panic("rove: go changeset up func is nil")
+	}
+	if r.goChangeset(author, id) != nil {
+		panic("rove: go changeset registered twice " + author + ":" + id)
+	}
+
+	r.goChangesets = append(r.goChangesets, goChangeset{
+		author:  author,
+		id:      id,
+		version: version,
+		up:      up,
+		down:    down,
+	})
+}
+
+// goChangeset returns the registered Go changeset or nil if it's not found.
+func (r *Rove) goChangeset(author, id string) *goChangeset {
+	for i, v := range r.goChangesets {
+		if v.author == author && v.id == id {
+			return &r.goChangesets[i]
+		}
+	}
+
+	return nil
+}
+
+// resolveGoChangesets will add the version of the Go changesets to their
+// placeholders and add the Go changesets without a placeholder to the end of
+// the array.
+func (r *Rove) resolveGoChangesets(arr []changeset.Record) ([]changeset.Record, error) {
+	placed := make(map[string]bool)
+	for i, cs := range arr {
+		if !cs.GoChangeset {
+			continue
+		}
+
+		g := r.goChangeset(cs.Author, cs.ID)
+		if g == nil {
+			return nil, fmt.Errorf("error on changeset %v:%v - %w", cs.Author, cs.ID,
+				ErrGoChangesetNotFound)
+		}
+		arr[i].AddChange(g.version)
+		placed[cs.Author+":"+cs.ID] = true
+	}
+
+	for _, g := range r.goChangesets {
+		if placed[g.author+":"+g.id] {
+			continue
+		}
+
+		// Ensure a migration file named go doesn't have the same changeset.
+		for _, cs := range arr {
+			if cs.Author == g.author && cs.ID == g.id && cs.Filename == goFilename {
+				return nil, fmt.Errorf("error on changeset %v:%v - duplicate entry found: %v:%v:%v",
+					g.author, g.id, g.author, g.id, goFilename)
+			}
+		}
+
+		cs := new(changeset.Record)
+		cs.Author = g.author
+		cs.ID = g.id
+		cs.SplitStatements = true
+		cs.SetFileInfo(goFilename, appVersion)
+		cs.GoChangeset = true
+		cs.AddChange(g.version)
+		arr = append(arr, *cs)
+	}
+
+	return arr, nil
+}
+
+// isGoChangeset returns true if the changeset runs Go functions.
+func isGoChangeset(cs changeset.Record) bool {
+	return cs.GoChangeset
+}
+
+// up returns the func to apply the changeset in a transaction.
+func (r *Rove) up(cs changeset.Record) func(tx Transaction) error {
+	if isGoChangeset(cs) {
+		return r.goFunc(cs, true)
+	}
+
+	return execStatements(cs.Statements(dialect(r.db)))
+}
+
+// down returns the func to roll back the changeset in a transaction.
+func (r *Rove) down(cs changeset.Record) func(tx Transaction) error {
+	if isGoChangeset(cs) {
+		return r.goFunc(cs, false)
+	}
+
+	return execStatements(cs.RollbackStatements(dialect(r.db)))
+}
+
+// goFunc returns the func to run the up or down function of the Go changeset
+// in a transaction.
+func (r *Rove) goFunc(cs changeset.Record, up bool) func(tx Transaction) error {
+	return func(tx Transaction) error {
+		g := r.goChangeset(cs.Author, cs.ID)
+		if g == nil {
+			return ErrGoChangesetNotFound
+		}
+
+		fn := g.down
+		if up {
+			fn = g.up
+		}
+		if fn == nil {
+			return nil
+		}
+
+		ctx := r.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		return fn(ctx, tx)
+	}
+}
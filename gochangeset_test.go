@@ -0,0 +1,155 @@
+package rove_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/josephspurrier/rove"
+	"github.com/josephspurrier/rove/pkg/changeset"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLiteGoChangeset(t *testing.T) {
+	s := newSQLite(t)
+
+	r := rove.NewChangesetMigration(s, `--changeset josephspurrier:1
+CREATE TABLE user_status (id INTEGER NOT NULL PRIMARY KEY, status VARCHAR(25) NOT NULL);
+--rollback DROP TABLE user_status;
+
+--go josephspurrier:2
+--description Add the statuses.
+
+--changeset josephspurrier:3
+INSERT INTO user_status (id, status) VALUES (3, 'deleted');
+--rollback DELETE FROM user_status WHERE id = 3;`)
+
+	// Ensure the changesets are registered once with the required fields.
+	up := func(ctx context.Context, tx rove.Transaction) error {
+		assert.NotNil(t, ctx)
+		if err := tx.Exec(`INSERT INTO user_status (id, status) VALUES (1, 'active')`); err != nil {
+			return err
+		}
+		_, err := tx.(rove.SQLTransaction).SQLTx().Exec(`INSERT INTO user_status (id, status) VALUES (?, ?)`,
+			2, "inactive")
+		return err
+	}
+	down := func(ctx context.Context, tx rove.Transaction) error {
+		return tx.Exec(`DELETE FROM user_status WHERE id IN (1, 2)`)
+	}
+	r.RegisterGoChangeset("josephspurrier", "2", "v1", up, down)
+	r.RegisterGoChangeset("josephspurrier", "4", "v1", func(ctx context.Context, tx rove.Transaction) error {
+		return tx.Exec(`UPDATE user_status SET status = 'removed' WHERE id = 3`)
+	}, nil)
+	assert.Panics(t, func() { r.RegisterGoChangeset("josephspurrier", "2", "v1", up, down) })
+	assert.Panics(t, func() { r.RegisterGoChangeset("josephspurrier", "5", "", up, down) })
+	assert.Panics(t, func() { r.RegisterGoChangeset("josephspurrier", "5", "v1", nil, down) })
+
+	// Ensure the dry run doesn't run the Go changesets.
+	buf := new(bytes.Buffer)
+	r.DryRun = buf
+	err := r.Migrate(0)
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), "-- Changeset josephspurrier:2 (go) checksum")
+	assert.Contains(t, buf.String(), "-- Changeset is a Go function that is not run on a dry run.")
+	r.DryRun = nil
+
+	// Run the migration.
+	err = r.Migrate(0)
+	assert.Nil(t, err)
+
+	arr := make([]string, 0)
+	err = s.DB.Select(&arr, `SELECT status FROM user_status ORDER BY id`)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"active", "inactive", "removed"}, arr)
+
+	// Ensure the Go changesets are recorded in the order of the placeholder
+	// and then the order they were registered.
+	records, err := s.Changesets(false)
+	assert.Nil(t, err)
+	order := make([]string, 0)
+	for _, v := range records {
+		order = append(order, v.ID+" "+v.Filename)
+	}
+	assert.Equal(t, []string{"1 memory", "2 go", "3 memory", "4 go"}, order)
+
+	// Ensure the checksum is of the version.
+	cs := new(changeset.Record)
+	cs.AddChange("v1")
+	assert.Equal(t, cs.GenerateChecksum(), records[1].Checksum)
+
+	st, err := r.Status()
+	assert.Nil(t, err)
+	if assert.NotNil(t, st) {
+		assert.True(t, st.Current())
+	}
+
+	// Remove all migrations.
+	err = r.Reset(0)
+	assert.Nil(t, err)
+
+	count := 1
+	err = s.DB.Get(&count, `SELECT COUNT(*) FROM rovechangelog`)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestSQLiteGoChangesetErrors(t *testing.T) {
+	// Ensure a placeholder requires a registered Go changeset.
+	r := rove.NewChangesetMigration(newSQLite(t), "--go josephspurrier:1")
+	err := r.Migrate(0)
+	assert.True(t, errors.Is(err, rove.ErrGoChangesetNotFound))
+
+	// Ensure a placeholder can't have SQL.
+	r = rove.NewChangesetMigration(newSQLite(t), "--go josephspurrier:1\nSELECT 1;")
+	err = r.Migrate(0)
+	assert.True(t, errors.Is(err, rove.ErrInvalidGoChangeset))
+	var pe *rove.ParseError
+	if assert.True(t, errors.As(err, &pe)) {
+		assert.Equal(t, 2, pe.Line)
+	}
+
+	// Ensure an error in a Go changeset is not recorded.
+	s := newSQLite(t)
+	r = rove.NewChangesetMigration(s, "--go josephspurrier:1")
+	r.RegisterGoChangeset("josephspurrier", "1", "v1", func(ctx context.Context, tx rove.Transaction) error {
+		return errors.New("failed")
+	}, nil)
+	err = r.Migrate(0)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "error on changeset josephspurrier:1 - failed")
+
+	count, err := s.Count()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, count)
+
+	// Ensure a migration file named go can't have a registered Go changeset.
+	fsys := fstest.MapFS{
+		"changelog.sql": &fstest.MapFile{Data: []byte("--include go")},
+		"go":            &fstest.MapFile{Data: []byte("--changeset josephspurrier:1\nCREATE TABLE a (id INTEGER);\n--rollback DROP TABLE a;")},
+	}
+	r = rove.NewFSMigration(newSQLite(t), fsys, "changelog.sql")
+	r.RegisterGoChangeset("josephspurrier", "1", "v1", func(ctx context.Context, tx rove.Transaction) error {
+		return nil
+	}, nil)
+	err = r.Migrate(0)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "duplicate entry found: josephspurrier:1:go")
+
+	// Ensure a migration file named go is not a Go changeset.
+	s = newSQLite(t)
+	r = rove.NewFSMigration(s, fsys, "changelog.sql")
+	err = r.Migrate(0)
+	assert.Nil(t, err)
+	exists, err := s.TableExists("a")
+	assert.Nil(t, err)
+	assert.True(t, exists)
+	err = r.Reset(0)
+	assert.Nil(t, err)
+	exists, err = s.TableExists("a")
+	assert.Nil(t, err)
+	assert.False(t, exists)
+}
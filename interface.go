@@ -1,6 +1,7 @@
 package rove
 
 import (
+	"database/sql"
 	"time"
 
 	"github.com/josephspurrier/rove/pkg/changeset"
@@ -23,22 +24,23 @@ type Changelog interface {
 	// an error.
 	Count() (count int, err error)
 	// Insert should add a new changeset to the changelog or return an error.
-	Insert(id, author, filename string, dateexecuted time.Time, count int,
-		checksum, description, version string) error
+	Insert(record changeset.Record) error
 	// Update should update a changeset in the changelog or return an error.
-	Update(id, author, filename string, dateexecuted time.Time, count int,
-		checksum, description, version string) error
+	Update(record changeset.Record) error
 	// Delete should remove the changeset from the changelog or return an error.
 	Delete(id, author, filename string) error
 	// Tag should add a tag to the latest changeset in the database or return
 	// an error.
 	Tag(id, author, filename, tag string) error
-	// Rollback should return the number of changests to remove to revert to the
-	// specified tag or return an error.
-	Rollback(tag string) (int, error)
+	// Rollback should return the changesets applied after the changeset with
+	// the tag in descending order of execution (orderexecuted) or return an
+	// error if the tag is not found or there are no changesets to remove.
+	Rollback(tag string) ([]changeset.Record, error)
 }
 
-// Transaction represents a changelog transaction.
+// Transaction represents a changelog transaction. The changelog operations
+// should run in the same transaction as the queries so a changeset and its
+// changelog record are committed together.
 type Transaction interface {
 	// Commit should attempt to commit the changes to to the changelog or
 	// return an error.
@@ -47,4 +49,96 @@ type Transaction interface {
 	Rollback() error
 	// Exec should prepare to make a change to the changelog.
 	Exec(query string) error
+	// Insert should add a new changeset to the changelog as part of the
+	// transaction or return an error.
+	Insert(record changeset.Record) error
+	// Update should update a changeset in the changelog as part of the
+	// transaction or return an error.
+	Update(record changeset.Record) error
+	// Delete should remove the changeset from the changelog as part of the
+	// transaction or return an error.
+	Delete(id, author, filename string) error
+}
+
+// SQLTransaction is an optional interface a Transaction can satisfy to give
+// the Go changesets access to the database transaction.
+type SQLTransaction interface {
+	// SQLTx should return the database transaction.
+	SQLTx() *sql.Tx
+}
+
+// TransactionalDDL is an optional interface a Changelog can satisfy when
+// schema changes are undone along with the transaction they run in. If a
+// Changelog doesn't satisfy the interface (or returns false), Rove records a
+// changeset as pending before it's applied so an interrupted changeset can be
+// detected and repaired on the next run.
+type TransactionalDDL interface {
+	// TransactionalDDL should return true if schema changes are part of the
+	// transaction.
+	TransactionalDDL() bool
+}
+
+// ChangelogExists is an optional interface a Changelog can satisfy so a dry
+// run doesn't have to create the changelog. If the changelog doesn't exist, a
+// dry run treats it as empty. If a Changelog doesn't satisfy the interface, a
+// dry run assumes the changelog exists.
+type ChangelogExists interface {
+	// ChangelogExists should return true if the changelog is set up or return
+	// an error.
+	ChangelogExists() (bool, error)
+}
+
+// StatementDialect is an optional interface a Changelog can satisfy to set the
+// SQL syntax used to split the changesets into statements. If a Changelog
+// doesn't satisfy the interface, changeset.DialectStandard is used.
+type StatementDialect interface {
+	// Dialect should return the SQL syntax of the database.
+	Dialect() changeset.Dialect
+}
+
+// Locker is an optional interface a Changelog can satisfy to prevent more than
+// one process from changing the changelog at the same time.
+type Locker interface {
+	// Lock should attempt to acquire the lock for the owner and return true if
+	// the lock was acquired, false if the lock is held by another owner, or an
+	// error.
+	Lock(owner, host string, acquired time.Time) (bool, error)
+	// Unlock should release the lock if it's held by the owner or return an
+	// error.
+	Unlock(owner string) error
+	// LockStatus should return the current lock, nil if the changelog is not
+	// locked, or an error.
+	LockStatus() (*Lock, error)
+	// ReleaseLock should release the lock regardless of the owner or return an
+	// error.
+	ReleaseLock() error
+	// TakeLock should give the lock to the owner only if it's still held by
+	// the stale owner and return true if the lock was taken, false if the lock
+	// changed, or an error.
+	TakeLock(stale, owner, host string, acquired time.Time) (bool, error)
+}
+
+// ChangelogSQL is an optional interface a Changelog can satisfy to output the
+// SQL that changes the changelog during a dry run. If a Changelog doesn't
+// satisfy the interface, the changelog SQL is left out of the dry run.
+type ChangelogSQL interface {
+	// InsertSQL should return the SQL to insert the record.
+	InsertSQL(record changeset.Record) string
+	// UpdateSQL should return the SQL to update the record.
+	UpdateSQL(record changeset.Record) string
+	// DeleteSQL should return the SQL to delete the record.
+	DeleteSQL(id, author, filename string) string
+}
+
+// Querier is an optional interface a Changelog can satisfy to evaluate
+// changeset preconditions.
+type Querier interface {
+	// QueryValue should return the first column of the first row of the query
+	// as a string or an error.
+	QueryValue(query string) (string, error)
+	// TableExists should return true if the table exists or an error.
+	TableExists(table string) (bool, error)
+	// ColumnExists should return true if the column exists on the table or an
+	// error.
+	ColumnExists(table, column string) (bool, error)
 }
@@ -6,6 +6,8 @@ import (
 	"log"
 	"time"
 
+	"github.com/josephspurrier/rove/pkg/changeset"
+
 	"github.com/jmoiron/sqlx"
 )
 
@@ -21,9 +23,16 @@ type LBChangelog struct {
 }
 
 // Convert convert a Liquibase table to a Rove table.
-func (r *Rove) Convert(db *sqlx.DB) error {
+func (r *Rove) Convert(db *sqlx.DB) (err error) {
+	// Prevent other processes from changing the changelog.
+	release, err := r.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock(release, &err)
+
 	// Create the object to store the changeset log.
-	err := r.db.Initialize()
+	err = r.db.Initialize()
 	if err != nil {
 		return fmt.Errorf("error on changelog creation: %v", err)
 	}
@@ -64,10 +73,22 @@ func (r *Rove) Convert(db *sqlx.DB) error {
 			return errors.New("changeset is missing: " + id)
 		}
 
+		checksum, err := r.checksum(newCS)
+		if err != nil {
+			return err
+		}
+
 		// Insert the record.
-		err = r.db.Insert(cs.ID, cs.Author, cs.Filename, cs.DateExecuted,
-			cs.OrderExecuted, newCS.GenerateChecksum(),
-			"", "liquibase "+cs.Version)
+		err = r.db.Insert(changeset.Record{
+			ID:            cs.ID,
+			Author:        cs.Author,
+			Filename:      cs.Filename,
+			DateExecuted:  cs.DateExecuted,
+			OrderExecuted: cs.OrderExecuted,
+			Checksum:      checksum,
+			Version:       "liquibase " + cs.Version,
+			ExecType:      changeset.ExecTypeExecuted,
+		})
 		if err != nil {
 			return fmt.Errorf("error on inserting changelog record: %v", err)
 		}
@@ -0,0 +1,164 @@
+package rove
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	// DefaultLockWait is how long to wait for the changelog lock if the wait
+	// is not set.
+	DefaultLockWait = 5 * time.Minute
+)
+
+var (
+	// lockPoll is how often to check if the changelog lock is released.
+	lockPoll = time.Second
+)
+
+// Lock is a lock on the changelog.
+type Lock struct {
+	Owner    string
+	Host     string
+	Acquired time.Time
+}
+
+// String returns a display of the lock.
+func (l *Lock) String() string {
+	return fmt.Sprintf("locked by %v (%v) since %v", l.Owner, l.Host,
+		l.Acquired.Format(time.RFC3339))
+}
+
+// lockOwner returns a unique owner for the changelog lock.
+func lockOwner(host string) string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%v-%v-%x", host, os.Getpid(), b)
+}
+
+// lock will acquire the changelog lock and return a func to release it. If the
+// changelog doesn't support locks or this is a dry run, the func does nothing.
+func (r *Rove) lock() (func() error, error) {
+	l, ok := r.db.(Locker)
+	if !ok || r.DryRun != nil {
+		return func() error { return nil }, nil
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	owner := lockOwner(host)
+
+	wait := r.LockWait
+	if wait <= 0 {
+		wait = DefaultLockWait
+	}
+	poll := lockPoll
+	if wait < poll {
+		poll = wait
+	}
+	deadline := time.Now().Add(wait)
+	waiting := false
+
+	for {
+		acquired, err := l.Lock(owner, host, time.Now())
+		if err != nil {
+			return nil, fmt.Errorf("error on acquiring lock: %v", err)
+		} else if acquired {
+			break
+		}
+
+		current, err := l.LockStatus()
+		if err != nil {
+			return nil, fmt.Errorf("error on lock status: %v", err)
+		}
+
+		// Take over the lock if it's stale. The lock is only taken if it's still
+		// held by the same owner so two processes can't both take it.
+		if current != nil && r.LockStale > 0 && time.Since(current.Acquired) > r.LockStale {
+			taken, err := l.TakeLock(current.Owner, owner, host, time.Now())
+			if err != nil {
+				return nil, fmt.Errorf("error on taking stale lock: %v", err)
+			} else if taken {
+				if r.Verbose {
+					fmt.Printf("Took over stale lock: %v\n", current.String())
+				}
+				break
+			}
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			if current != nil {
+				return nil, fmt.Errorf("could not acquire lock after %v - %v", wait, current.String())
+			}
+			return nil, fmt.Errorf("could not acquire lock after %v", wait)
+		}
+
+		if r.Verbose && current != nil && !waiting {
+			fmt.Printf("Waiting for lock: %v\n", current.String())
+		}
+		waiting = true
+
+		time.Sleep(poll)
+	}
+
+	return func() error {
+		return l.Unlock(owner)
+	}, nil
+}
+
+// unlock will release the changelog lock and return the first error that
+// occurred.
+func unlock(release func() error, err *error) {
+	errr := release()
+	if *err == nil && errr != nil {
+		*err = fmt.Errorf("error on releasing lock: %v", errr)
+	}
+}
+
+// LockStatus will output the status of the changelog lock and return the
+// lock, nil if the changelog is not locked, or an error.
+func (r *Rove) LockStatus() (*Lock, error) {
+	l, ok := r.db.(Locker)
+	if !ok {
+		return nil, fmt.Errorf("error - changelog does not support locks")
+	}
+
+	current, err := l.LockStatus()
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Verbose {
+		if current != nil {
+			fmt.Printf("Changelog %v\n", current.String())
+		} else {
+			fmt.Println("Changelog is not locked.")
+		}
+	}
+
+	return current, nil
+}
+
+// ReleaseLock will release the changelog lock regardless of the owner.
+func (r *Rove) ReleaseLock() error {
+	l, ok := r.db.(Locker)
+	if !ok {
+		return fmt.Errorf("error - changelog does not support locks")
+	}
+
+	err := l.ReleaseLock()
+	if err != nil {
+		return err
+	}
+
+	if r.Verbose {
+		fmt.Println("Changelog lock released.")
+	}
+
+	return nil
+}
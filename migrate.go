@@ -2,58 +2,131 @@ package rove
 
 import (
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/josephspurrier/rove/pkg/changeset"
 )
 
 // Migrate will perform all the migrations in a file. If max is 0, all
-// migrations are run.
-func (r *Rove) Migrate(max int) error {
+// migrations are run. If DryRun is set, the SQL is written to it instead.
+func (r *Rove) Migrate(max int) (err error) {
+	return r.migrate(max, "")
+}
+
+// MigrateTo will perform the migrations in a file up to and including the
+// target. The target is a tag from a tagDatabase in the file or a changeset
+// in the format author:id. If DryRun is set, the SQL is written to it instead.
+func (r *Rove) MigrateTo(target string) (err error) {
+	if len(target) == 0 {
+		return fmt.Errorf("error - migrate target cannot be empty")
+	}
+
+	return r.migrate(0, target)
+}
+
+// migrate will perform the migrations in a file up to the target. If max is
+// 0, all migrations are run. If the target is blank, there is no target.
+func (r *Rove) migrate(max int, target string) (err error) {
+	// Prevent other processes from changing the changelog.
+	release, err := r.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock(release, &err)
+
 	// Create the object to store the changeset log.
-	err := r.db.Initialize()
+	err = r.initialize()
 	if err != nil {
-		return fmt.Errorf("error on changelog creation: %v", err)
+		return err
 	}
 
-	var arr []changeset.Record
+	// Get the changesets.
+	arr, err := r.loadChangesetArray()
+	if err != nil {
+		return err
+	}
 
-	// If a file is specified, use it to build the array.
-	if len(r.file) > 0 {
-		// Get the changesets.
-		arr, err = parseFileToArray(r.file)
+	// Only perform the migrations up to and including the target.
+	if len(target) > 0 {
+		i, err := targetIndex(arr, target)
 		if err != nil {
-			return fmt.Errorf("error parsing file: %v", err)
-		}
-	} else {
-		// Else use the changeset that was passed in.
-		arr, err = parseToArray(strings.NewReader(r.changeset), elementMemory)
-		if err != nil {
-			return fmt.Errorf("error on parsing string: %v", err)
+			return err
 		}
+		arr = arr[:i+1]
 	}
 
 	if r.Verbose {
-		fmt.Printf("Changesets applied (request: %v):\n", max)
+		if len(target) > 0 {
+			fmt.Printf("Changesets applied (target: %v):\n", target)
+		} else {
+			fmt.Printf("Changesets applied (request: %v):\n", max)
+		}
 	}
 
 	maxCounter := 0
+	inserted := 0
 
 	// Loop through each changeset.
 	for _, cs := range arr {
-		newChecksum := cs.GenerateChecksum()
+		newChecksum, err := r.checksum(cs)
+		if err != nil {
+			return err
+		}
 
 		// Determine if the changeset was already applied.
 		// Count the number of rows.
-		record, err := r.db.ChangesetApplied(cs.ID, cs.Author, cs.Filename)
+		record, err := r.changesetApplied(cs)
 		if err != nil {
 			return fmt.Errorf("internal error on changeset %v:%v - %v", cs.Author, cs.ID, err.Error())
-		} else if record != nil {
+		}
+
+		// Finish an interrupted rollback before applying the changeset again.
+		if record != nil && record.ExecType == changeset.ExecTypeRollbackPending {
+			if r.DryRun != nil {
+				err = r.writeRollback(cs, *record)
+			} else {
+				err = r.rollback(cs, *record)
+			}
+			if err != nil {
+				return err
+			}
+
+			if r.Verbose {
+				fmt.Printf("Repaired rollback: %v\n", record.String())
+			}
+			record = nil
+		}
+
+		// Stop on a changeset that was interrupted because its changes may be
+		// committed even though the record is pending.
+		if record != nil && record.ExecType == changeset.ExecTypePending {
+			return interruptedError(*record)
+		}
+
+		rerun := false
+		if record != nil {
 			comment := ""
 
-			// Determine if the checksums match.
-			if record.Checksum != newChecksum {
+			changed, err := checksumChanged(cs, *record)
+			if err != nil {
+				return err
+			}
+
+			// Determine if the checksums match. A changeset that runs on change is
+			// applied again instead.
+			if changed && cs.IsValidChecksum(record.Checksum) {
+				// Accept a checksum listed as valid for the changeset.
+				changed = false
+				if r.Checksum == ChecksumUpdate {
+					comment = fmt.Sprintf("Updated valid checksum from (%v) to (%v)\n", record.Checksum, newChecksum)
+					record, err = r.updateChecksum(*record, newChecksum, "Update")
+					if err != nil {
+						return err
+					}
+				} else {
+					comment = fmt.Sprintf("Accepting valid checksum (%v), currently (%v)\n", record.Checksum, newChecksum)
+				}
+			} else if changed && !cs.RunOnChange {
 				if r.Checksum == ChecksumThrowError {
 					return fmt.Errorf("checksum does not match - existing changeset %v:%v has checksum %v, but new changeset has checksum %v",
 						cs.Author, cs.ID, record.Checksum, newChecksum)
@@ -62,76 +135,238 @@ func (r *Rove) Migrate(max int) error {
 					comment = fmt.Sprintf("Ignoring checksum (%v), should be (%v)\n", record.Checksum, newChecksum)
 				} else if r.Checksum == ChecksumUpdate {
 					// Update the checksum.
-					err = r.db.Update(record.ID, record.Author, record.Filename,
-						record.DateExecuted, record.OrderExecuted, newChecksum,
-						record.Description, record.Version)
+					comment = fmt.Sprintf("Updated checksum from (%v) to (%v)\n", record.Checksum, newChecksum)
+					record, err = r.updateChecksum(*record, newChecksum, "Update")
 					if err != nil {
-						return fmt.Errorf("internal error on updating changeset %v:%v - %v", cs.Author, cs.ID, err.Error())
-
+						return err
 					}
-					comment = fmt.Sprintf("Updated checksum from (%v) to (%v)\n", record.Checksum, newChecksum)
-
+				}
+			} else if !changed && record.Checksum != newChecksum {
+				// Upgrade a matching checksum of another version.
+				comment = fmt.Sprintf("Upgraded checksum from (%v) to (%v)\n", record.Checksum, newChecksum)
+				record, err = r.updateChecksum(*record, newChecksum, "Upgrade")
+				if err != nil {
+					return err
 				}
 			}
 
-			if r.Verbose {
-				fmt.Printf("Already applied: %v\n", record.String())
-				if len(comment) > 0 {
-					fmt.Printf("%v", comment)
+			// Determine if the changeset should be applied again.
+			rerun = cs.RunAlways || (cs.RunOnChange && changed)
+
+			if !rerun {
+				if r.Verbose {
+					fmt.Printf("Already applied: %v\n", record.String())
+					if len(comment) > 0 {
+						fmt.Printf("%v", comment)
+					}
 				}
+				continue
 			}
-			continue
-		}
 
-		tx, err := r.db.BeginTx()
-		if err != nil {
-			return fmt.Errorf("error on begin transaction - %v", err.Error())
+			if r.Verbose && len(comment) > 0 {
+				fmt.Printf("%v", comment)
+			}
 		}
 
-		// Execute the query.
-		err = tx.Exec(cs.Changes())
-		if err != nil {
-			return fmt.Errorf("error on changeset %v:%v - %v", cs.Author, cs.ID, err.Error())
+		// Check the preconditions of a changeset that's not applied yet.
+		policy := ""
+		if record == nil && len(cs.Preconditions) > 0 {
+			policy, err = r.checkPreconditions(cs)
+			if err != nil {
+				return err
+			}
 		}
 
-		err = tx.Commit()
-		if err != nil {
-			errr := tx.Rollback()
-			if errr != nil {
-				return fmt.Errorf("error on commit rollback %v:%v - %v", cs.Author, cs.ID, errr.Error())
+		if rerun {
+			// Apply the changeset again and update the record.
+			if r.DryRun != nil {
+				err = r.writeRerun(cs, *record)
+			} else {
+				err = r.rerun(cs, *record)
+			}
+			if err != nil {
+				return err
+			}
+		} else if policy == changeset.OnContinue {
+			// Skip the changeset so it's checked again on the next migration.
+			continue
+		} else if policy == changeset.OnMarkRan {
+			// Record the changeset without applying it.
+			err = r.markRan(cs, inserted, "a precondition failed")
+			if err != nil {
+				return err
+			}
+			if r.DryRun != nil {
+				inserted++
+			}
+		} else if r.DryRun != nil {
+			// Write the changeset instead of applying it on a dry run.
+			err = r.writeApply(cs, inserted)
+			if err != nil {
+				return err
+			}
+			inserted++
+		} else {
+			// Apply the changeset.
+			err = r.apply(cs)
+			if err != nil {
+				return err
+			}
+
+			// Query back the record.
+			newRecord, err := r.db.ChangesetApplied(cs.ID, cs.Author, cs.Filename)
+			if err != nil {
+				return fmt.Errorf("error on querying changelog record: %v", err)
+			}
+
+			if r.Verbose {
+				fmt.Printf("Applied: %v\n", newRecord.String())
 			}
-			return fmt.Errorf("error on commit %v:%v - %v", cs.Author, cs.ID, err.Error())
 		}
 
-		// Count the number of rows.
-		count, err := r.db.Count()
-		if err != nil {
-			return fmt.Errorf("error on counting changelog rows: %v", err)
+		// Only perform the maximum number of changes based on the max value.
+		maxCounter++
+		if max != 0 && maxCounter >= max {
+			break
 		}
+	}
 
-		// Insert the record.
-		err = r.db.Insert(cs.ID, cs.Author, cs.Filename, time.Now(), count+1,
-			newChecksum, cs.Description, cs.Version)
-		if err != nil {
-			return fmt.Errorf("error on inserting changelog record: %v", err)
+	return nil
+}
+
+// targetIndex returns the index of the changeset with the tag or the first
+// changeset that matches author:id.
+func targetIndex(arr []changeset.Record, target string) (int, error) {
+	for i, cs := range arr {
+		if cs.Tag == target {
+			return i, nil
 		}
+	}
 
-		// Query back the record.
-		newRecord, err := r.db.ChangesetApplied(cs.ID, cs.Author, cs.Filename)
-		if err != nil {
-			return fmt.Errorf("error on querying changelog record: %v", err)
+	for i, cs := range arr {
+		if cs.Author+":"+cs.ID == target {
+			return i, nil
 		}
+	}
+
+	return 0, fmt.Errorf("error - target not found in the migration file: %v", target)
+}
+
+// updateChecksum will update the checksum of the record in the changelog or
+// write the update on a dry run. The action is written in the dry run comment.
+func (r *Rove) updateChecksum(record changeset.Record, checksum string, action string) (*changeset.Record, error) {
+	updated := record
+	updated.Checksum = checksum
+
+	var err error
+	if r.DryRun != nil {
+		err = r.writeUpdate(updated, fmt.Sprintf("%v checksum from (%v) to (%v)",
+			action, record.Checksum, checksum))
+	} else {
+		err = r.db.Update(updated)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("internal error on updating changeset %v:%v - %v", record.Author, record.ID, err.Error())
+	}
+
+	return &updated, nil
+}
+
+// apply will run the changeset and record it in the changelog. If the
+// changelog doesn't support transactional DDL, the record is inserted as
+// pending before the changeset runs and then marked as executed in the same
+// transaction as the changeset.
+func (r *Rove) apply(cs changeset.Record) error {
+	checksum, err := r.checksum(cs)
+	if err != nil {
+		return err
+	}
+
+	// Count the number of rows.
+	count, err := r.db.Count()
+	if err != nil {
+		return fmt.Errorf("error on counting changelog rows: %v", err)
+	}
 
-		if r.Verbose {
-			fmt.Printf("Applied: %v\n", newRecord.String())
+	record := cs
+	record.OrderExecuted = count + 1
+	record.DateExecuted = time.Now()
+	record.Checksum = checksum
+	record.ExecType = changeset.ExecTypeExecuted
+
+	// Insert the record in the same transaction as the changeset.
+	changelog := func(tx Transaction) error {
+		return tx.Insert(record)
+	}
+
+	if !transactionalDDL(r.db) {
+		// Insert a pending record before applying the changeset because the
+		// changeset may be committed even if the transaction is not.
+		p := record
+		p.ExecType = changeset.ExecTypePending
+		err := r.db.Insert(p)
+		if err != nil {
+			return fmt.Errorf("error on inserting changelog record: %v", err)
 		}
 
-		// Only perform the maximum number of changes based on the max value.
-		maxCounter++
-		if max != 0 && maxCounter >= max {
-			break
+		// Mark the pending record as executed.
+		changelog = func(tx Transaction) error {
+			return tx.Update(record)
 		}
 	}
 
+	// Execute the query.
+	err = r.execTx(r.up(cs), changelog)
+	if err != nil {
+		return fmt.Errorf("error on changeset %v:%v - %v", cs.Author, cs.ID, err.Error())
+	}
+
+	return nil
+}
+
+// interruptedError returns the error for a changeset with a pending record.
+// The changes of the changeset may be partly committed so it's not applied
+// again. Once the database is fixed, the record is marked as executed with
+// MarkApplied.
+func interruptedError(record changeset.Record) error {
+	return fmt.Errorf("error - changeset was interrupted and may be partly applied, fix the database "+
+		"and then mark it as applied with sync: %v:%v:%v", record.Author, record.ID, record.Filename)
+}
+
+// rerunRecord returns the record of the changeset applied again. The checksum
+// is only updated if the changeset runs on change.
+func (r *Rove) rerunRecord(cs changeset.Record, applied changeset.Record) (changeset.Record, error) {
+	var err error
+	record := applied
+	record.Reruns++
+	record.LastRerun = time.Now()
+	record.ExecType = changeset.ExecTypeReran
+	if cs.RunOnChange {
+		record.Checksum, err = r.checksum(cs)
+	}
+
+	return record, err
+}
+
+// rerun will run the changeset again and update the record in the changelog
+// in the same transaction.
+func (r *Rove) rerun(cs changeset.Record, applied changeset.Record) error {
+	record, err := r.rerunRecord(cs, applied)
+	if err != nil {
+		return err
+	}
+
+	// Execute the query.
+	err = r.execTx(r.up(cs), func(tx Transaction) error {
+		return tx.Update(record)
+	})
+	if err != nil {
+		return fmt.Errorf("error on changeset %v:%v - %v", cs.Author, cs.ID, err.Error())
+	}
+
+	if r.Verbose {
+		fmt.Printf("Reran: %v\n", record.String())
+	}
+
 	return nil
 }
@@ -10,6 +10,7 @@ import (
 	"github.com/josephspurrier/rove"
 	"github.com/josephspurrier/rove/pkg/adapter/mysql"
 	"github.com/josephspurrier/rove/pkg/adapter/mysql/testutil"
+	"github.com/josephspurrier/rove/pkg/changeset"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -181,8 +182,8 @@ func TestFileMigration(t *testing.T) {
 		// Get the status.
 		s, err := r.Status()
 		assert.Nil(t, err)
-		assert.Equal(t, "3", s.ID)
-		assert.Equal(t, "josephspurrier", s.Author)
+		assert.Equal(t, "3", s.Last.ID)
+		assert.Equal(t, "josephspurrier", s.Last.Author)
 
 		// Run migration again.
 		err = r.Migrate(0)
@@ -195,7 +196,7 @@ func TestFileMigration(t *testing.T) {
 		// Get the status.
 		s, err = r.Status()
 		assert.Nil(t, err)
-		assert.Nil(t, s)
+		assert.Nil(t, s.Last)
 
 		// Remove all migrations again.
 		err = r.Reset(0)
@@ -208,8 +209,8 @@ func TestFileMigration(t *testing.T) {
 		// Get the status.
 		s, err = r.Status()
 		assert.Nil(t, err)
-		assert.Equal(t, "2", s.ID)
-		assert.Equal(t, "josephspurrier", s.Author)
+		assert.Equal(t, "2", s.Last.ID)
+		assert.Equal(t, "josephspurrier", s.Last.Author)
 
 		// Remove 1 migration.
 		err = r.Reset(1)
@@ -218,8 +219,8 @@ func TestFileMigration(t *testing.T) {
 		// Show status of the migrations.
 		s, err = r.Status()
 		assert.Nil(t, err)
-		assert.Equal(t, "1", s.ID)
-		assert.Equal(t, "josephspurrier", s.Author)
+		assert.Equal(t, "1", s.Last.ID)
+		assert.Equal(t, "josephspurrier", s.Last.Author)
 
 		testutil.TeardownDatabase(unique)
 	}
@@ -240,9 +241,10 @@ func TestMigrationFailDuplicate(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.Contains(t, err.Error(), "duplicate entry found")
 
-	// Get the status.
+	// Ensure the status has the same parse error.
 	s, err := r.Status()
-	assert.Nil(t, err)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "duplicate entry found")
 	assert.Nil(t, s)
 
 	testutil.TeardownDatabase(unique)
@@ -264,8 +266,17 @@ func TestMigrationFailChecksum(t *testing.T) {
 	assert.Nil(t, err)
 
 	// Change a checksum.
-	err = m.Update("1", "josephspurrier", "success.sql", time.Now(), 1,
-		"bad", "description", "version")
+	err = m.Update(changeset.Record{
+		ID:            "1",
+		Author:        "josephspurrier",
+		Filename:      "success.sql",
+		DateExecuted:  time.Now(),
+		OrderExecuted: 1,
+		Checksum:      "bad",
+		Description:   "description",
+		Version:       "version",
+		ExecType:      changeset.ExecTypeExecuted,
+	})
 	assert.Nil(t, err)
 
 	// Migrate and throw error.
@@ -316,8 +327,8 @@ func TestInclude(t *testing.T) {
 	// Get the status.
 	s, err := r.Status()
 	assert.Nil(t, err)
-	assert.Equal(t, "3", s.ID)
-	assert.Equal(t, "josephspurrier", s.Author)
+	assert.Equal(t, "3", s.Last.ID)
+	assert.Equal(t, "josephspurrier", s.Last.Author)
 
 	// Run migration again.
 	err = r.Migrate(0)
@@ -330,7 +341,7 @@ func TestInclude(t *testing.T) {
 	// Get the status.
 	s, err = r.Status()
 	assert.Nil(t, err)
-	assert.Nil(t, s)
+	assert.Nil(t, s.Last)
 
 	// Remove all migrations again.
 	err = r.Reset(0)
@@ -343,8 +354,8 @@ func TestInclude(t *testing.T) {
 	// Get the status.
 	s, err = r.Status()
 	assert.Nil(t, err)
-	assert.Equal(t, "2", s.ID)
-	assert.Equal(t, "josephspurrier", s.Author)
+	assert.Equal(t, "2", s.Last.ID)
+	assert.Equal(t, "josephspurrier", s.Last.Author)
 
 	// Remove 1 migration.
 	err = r.Reset(1)
@@ -353,8 +364,8 @@ func TestInclude(t *testing.T) {
 	// Get the status.
 	s, err = r.Status()
 	assert.Nil(t, err)
-	assert.Equal(t, "1", s.ID)
-	assert.Equal(t, "josephspurrier", s.Author)
+	assert.Equal(t, "1", s.Last.ID)
+	assert.Equal(t, "josephspurrier", s.Last.Author)
 
 	testutil.TeardownDatabase(unique)
 }
@@ -384,9 +395,9 @@ func TestChangesetTag(t *testing.T) {
 	// Get the status.
 	s, err := r.Status()
 	assert.Nil(t, err)
-	assert.Equal(t, "1", s.ID)
-	assert.Equal(t, "josephspurrier", s.Author)
-	assert.Equal(t, "jas1", s.Tag)
+	assert.Equal(t, "1", s.Last.ID)
+	assert.Equal(t, "josephspurrier", s.Last.Author)
+	assert.Equal(t, "jas1", s.Last.Tag)
 
 	// Run migration again.
 	err = r.Migrate(1)
@@ -395,9 +406,9 @@ func TestChangesetTag(t *testing.T) {
 	// Get the status.
 	s, err = r.Status()
 	assert.Nil(t, err)
-	assert.Equal(t, "2", s.ID)
-	assert.Equal(t, "josephspurrier", s.Author)
-	assert.Equal(t, "", s.Tag)
+	assert.Equal(t, "2", s.Last.ID)
+	assert.Equal(t, "josephspurrier", s.Last.Author)
+	assert.Equal(t, "", s.Last.Tag)
 
 	// Rollback to the tag.
 	err = r.Rollback("jas1")
@@ -406,9 +417,9 @@ func TestChangesetTag(t *testing.T) {
 	// Get the status.
 	s, err = r.Status()
 	assert.Nil(t, err)
-	assert.Equal(t, "1", s.ID)
-	assert.Equal(t, "josephspurrier", s.Author)
-	assert.Equal(t, "jas1", s.Tag)
+	assert.Equal(t, "1", s.Last.ID)
+	assert.Equal(t, "josephspurrier", s.Last.Author)
+	assert.Equal(t, "jas1", s.Last.Tag)
 
 	// Attempt rollback again.
 	err = r.Rollback("jas1")
@@ -417,9 +428,9 @@ func TestChangesetTag(t *testing.T) {
 	// Get the status.
 	s, err = r.Status()
 	assert.Nil(t, err)
-	assert.Equal(t, "1", s.ID)
-	assert.Equal(t, "josephspurrier", s.Author)
-	assert.Equal(t, "jas1", s.Tag)
+	assert.Equal(t, "1", s.Last.ID)
+	assert.Equal(t, "josephspurrier", s.Last.Author)
+	assert.Equal(t, "jas1", s.Last.Tag)
 
 	// Run migration again.
 	err = r.Migrate(1)
@@ -529,6 +540,21 @@ func (t *TxMock) Exec(query string) error {
 	return t.ExecError
 }
 
+// Insert will add a changeset to the changelog.
+func (t *TxMock) Insert(record changeset.Record) error {
+	return nil
+}
+
+// Update will update a changeset in the changelog.
+func (t *TxMock) Update(record changeset.Record) error {
+	return nil
+}
+
+// Delete will remove a changeset from the changelog.
+func (t *TxMock) Delete(id, author, filename string) error {
+	return nil
+}
+
 func TestConvert(t *testing.T) {
 	_, unique := testutil.SetupDatabase()
 
@@ -539,8 +565,10 @@ func TestConvert(t *testing.T) {
 	// Create the Liquibase database table.
 	b, err := ioutil.ReadFile("testdata/lbsetup.sql")
 	assert.Nil(t, err)
-	_, err = m.DB.Exec(string(b))
-	assert.Nil(t, err)
+	for _, st := range changeset.SplitStatements(string(b), "", m.Dialect()) {
+		_, err = m.DB.Exec(st.Query)
+		assert.Nil(t, err)
+	}
 
 	// Set up rove.
 	r := rove.NewFileMigration(m, "testdata/success.sql")
@@ -553,9 +581,9 @@ func TestConvert(t *testing.T) {
 	// Get the status.
 	s, err := r.Status()
 	assert.Nil(t, err)
-	assert.Equal(t, "3", s.ID)
-	assert.Equal(t, "josephspurrier", s.Author)
-	assert.Equal(t, "", s.Tag)
+	assert.Equal(t, "3", s.Last.ID)
+	assert.Equal(t, "josephspurrier", s.Last.Author)
+	assert.Equal(t, "", s.Last.Tag)
 
 	testutil.TeardownDatabase(unique)
 }
@@ -5,9 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"os"
+	"io/fs"
 	"path"
-	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/josephspurrier/rove/pkg/changeset"
@@ -17,53 +18,281 @@ const (
 	elementChangeset   = "--changeset "
 	elementRollback    = "--rollback "
 	elementInclude     = "--include "
+	elementIncludeAll  = "--includeAll "
 	elementDescription = "--description "
-	elementMemory      = "memory"
+	elementValidSum    = "--validCheckSum "
+	elementProperty    = "--property "
+	elementGo          = "--go "
+	elementTagDatabase = "--tagDatabase "
+
+	elementPreconditions        = "--preconditions"
+	elementPreconditionSQLCheck = "--precondition-sql-check "
+	elementPreconditionTable    = "--precondition-table-exists "
+	elementPreconditionColumn   = "--precondition-column-exists "
+	elementMemory               = "memory"
+
+	extensionSQL  = ".sql"
+	extensionYAML = ".yaml"
+	extensionYML  = ".yml"
+	extensionJSON = ".json"
+
+	// maxSnippet is the maximum length of the line in a ParseError.
+	maxSnippet = 200
 )
 
 var (
 	// ErrInvalidFormat is when a changeset is not found.
 	ErrInvalidFormat = errors.New("invalid changeset format")
+	// ErrInvalidProperty is when a property is not in the format key=value.
+	ErrInvalidProperty = errors.New("invalid property")
+	// ErrInvalidInclude is when an include is missing the file or has an
+	// invalid attribute.
+	ErrInvalidInclude = errors.New("invalid include")
+	// ErrIncludeNotFound is when no files match the include pattern.
+	ErrIncludeNotFound = errors.New("no files match the include")
+	// ErrIncludeCycle is when a file includes itself directly or through
+	// other files.
+	ErrIncludeCycle = errors.New("include cycle")
+	// ErrInvalidTag is when a tagDatabase is missing the tag or the changeset
+	// already has a tag.
+	ErrInvalidTag = errors.New("invalid tag")
 )
 
-// parseFileToArray will parse a file into changesets.
-func parseFileToArray(filename string) ([]changeset.Record, error) {
-	f, err := os.Open(filename)
+// ParseError is an error in a migration file with the location of the error.
+type ParseError struct {
+	// File is the migration file.
+	File string
+	// Line is the line number in the file starting at 1 or 0 if the line is
+	// unknown.
+	Line int
+	// Column is the column number in the line starting at 1.
+	Column int
+	// Snippet is the text of the line, truncated if the line is long.
+	Snippet string
+	// Err is the underlying error.
+	Err error
+}
+
+// Error returns the location and the error. The line and column are omitted
+// when they are unknown like in a YAML or JSON file.
+func (e *ParseError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%v: %v: %v", e.File, e.Err.Error(), e.Snippet)
+	}
+
+	return fmt.Sprintf("%v:%v:%v: %v: %v", e.File, e.Line, e.Column, e.Err.Error(),
+		e.Snippet)
+}
+
+// Unwrap returns the underlying error.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// position is the location of a changeset header in a migration file.
+type position struct {
+	file    string
+	line    int
+	column  int
+	snippet string
+}
+
+// location returns the file and line of the position or the file and snippet
+// if the line is unknown.
+func (p position) location() string {
+	if p.line == 0 {
+		return fmt.Sprintf("%v %v", p.file, p.snippet)
+	}
+
+	return fmt.Sprintf("%v:%v", p.file, p.line)
+}
+
+// parseError returns a ParseError at the position. Long lines are truncated in
+// the snippet.
+func (p position) parseError(err error) *ParseError {
+	snippet := p.snippet
+	if len(snippet) > maxSnippet {
+		snippet = snippet[:maxSnippet] + "..."
+	}
+
+	return &ParseError{
+		File:    p.file,
+		Line:    p.line,
+		Column:  p.column,
+		Snippet: snippet,
+		Err:     err,
+	}
+}
+
+// parseFileToArray will parse a file from the file system into changesets. The
+// properties defined in the file are added to the properties.
+func parseFileToArray(fsys fs.FS, filename string, properties map[string]string) ([]changeset.Record, error) {
+	f, err := fsys.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return parseToArray(fsys, f, filename, properties)
+}
+
+// parseToArray will split the migration into an ordered array. Includes are
+// loaded from the file system relative to the filename. The properties defined
+// in the migration are added to the properties.
+func parseToArray(fsys fs.FS, r io.Reader, filename string, properties map[string]string) ([]changeset.Record, error) {
+	p := &parser{
+		fsys:       fsys,
+		properties: properties,
+	}
+
+	arr, positions, err := p.parse(r, filename)
 	if err != nil {
 		return nil, err
 	}
+
+	// Perform a verification check on duplicates.
+	found := make(map[string]position)
+	for i, cs := range arr {
+		id := fmt.Sprintf("%v:%v:%v", cs.Author, cs.ID, cs.Filename)
+		if first, ok := found[id]; ok {
+			return nil, positions[i].parseError(fmt.Errorf("duplicate entry found: %v (first defined at %v)",
+				id, first.location()))
+		}
+		found[id] = positions[i]
+	}
+
+	return arr, nil
+}
+
+// parser holds the state while parsing a migration file and its includes.
+type parser struct {
+	// fsys is the file system to load the includes from.
+	fsys fs.FS
+	// properties are the properties defined in the files. A property is only
+	// added if it's not already defined so the first definition is used.
+	properties map[string]string
+	// files are the files being parsed from the first file to the current
+	// include to detect an include cycle.
+	files []string
+}
+
+// parseFile will parse a file from the file system into changesets and the
+// positions of their headers.
+func (p *parser) parseFile(filename string) ([]changeset.Record, []position, error) {
+	f, err := p.fsys.Open(filename)
+	if err != nil {
+		return nil, nil, err
+	}
 	defer f.Close()
 
-	return parseToArray(f, filename)
+	return p.parse(f, filename)
+}
+
+// parse will split the migration into an ordered array along with the position
+// of each changeset header. The format of the migration is from the extension
+// of the filename: YAML for .yaml or .yml, JSON for .json, and SQL for the
+// rest.
+func (p *parser) parse(r io.Reader, filename string) ([]changeset.Record, []position, error) {
+	p.files = append(p.files, path.Clean(filename))
+	defer func() {
+		p.files = p.files[:len(p.files)-1]
+	}()
+
+	switch strings.ToLower(path.Ext(filename)) {
+	case extensionYAML, extensionYML:
+		return p.parseStructured(r, filename, decodeYAML)
+	case extensionJSON:
+		return p.parseStructured(r, filename, decodeJSON)
+	}
+
+	return p.parseSQL(r, filename)
 }
 
-// parseToArray will split the migration into an ordered array.
-func parseToArray(r io.Reader, filename string) ([]changeset.Record, error) {
-	scanner := bufio.NewScanner(r)
-	scanner.Split(bufio.ScanLines)
+// parseSQL will split the SQL migration into an ordered array along with the
+// position of each changeset header.
+func (p *parser) parseSQL(r io.Reader, filename string) ([]changeset.Record, []position, error) {
+	br := bufio.NewReader(r)
 
 	// Array of changesets.
 	arr := make([]changeset.Record, 0)
+	positions := make([]position, 0)
+
+	lineNumber := 0
+	for {
+		raw, err := readLine(br)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, nil, position{file: filename, line: lineNumber + 1, column: 1}.parseError(err)
+		}
+		lineNumber++
 
-	for scanner.Scan() {
 		// Get the line without leading or trailing spaces.
-		line := strings.TrimSpace(scanner.Text())
+		line := strings.TrimSpace(raw)
+
+		// Get the position of the line.
+		pos := position{
+			file:    filename,
+			line:    lineNumber,
+			column:  strings.Index(raw, line) + 1,
+			snippet: line,
+		}
 
 		// Skip blank lines or liquibase header.
 		if len(line) == 0 || strings.HasPrefix(line, "--liquibase") {
 			continue
 		}
 
-		// Determine if the line is an `include`.
-		if strings.HasPrefix(line, elementInclude) {
-			// Load the file and add to the array.
-			fp := strings.TrimPrefix(line, elementInclude)
-			rfp := filepath.Join(filepath.Dir(filename), fp)
-			cs, err := parseFileToArray(rfp)
+		// Determine if the line is an `include` or an `includeAll`.
+		if strings.HasPrefix(line, elementInclude) || strings.HasPrefix(line, elementIncludeAll) {
+			var files []string
+			if strings.HasPrefix(line, elementInclude) {
+				pos.column += len(elementInclude)
+				files, err = p.glob(filename, strings.TrimPrefix(line, elementInclude))
+			} else {
+				pos.column += len(elementIncludeAll)
+				dir, recursive, perr := parseIncludeAll(strings.TrimPrefix(line, elementIncludeAll))
+				if perr != nil {
+					return nil, nil, pos.parseError(perr)
+				}
+				files, err = p.dir(filename, dir, recursive)
+			}
+			if err != nil {
+				return nil, nil, pos.parseError(err)
+			}
+
+			// Load each file and add to the array.
+			cs, ps, err := p.include(pos, files)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 			arr = append(arr, cs...)
+			positions = append(positions, ps...)
+			continue
+		}
+
+		// Determine if the line is a property.
+		if strings.HasPrefix(line, elementProperty) {
+			err = p.addProperty(strings.TrimPrefix(line, elementProperty))
+			if err != nil {
+				pos.column += len(elementProperty)
+				return nil, nil, pos.parseError(err)
+			}
+			continue
+		}
+
+		// Determine if the line is a placeholder for a Go changeset.
+		if strings.HasPrefix(line, elementGo) {
+			cs := new(changeset.Record)
+			err := cs.ParseHeader(strings.TrimPrefix(line, elementGo))
+			if err != nil {
+				pos.column += len(elementGo)
+				return nil, nil, pos.parseError(err)
+			}
+			cs.SetFileInfo(goFilename, appVersion)
+			cs.GoChangeset = true
+			arr = append(arr, *cs)
+			positions = append(positions, pos)
 			continue
 		}
 
@@ -71,19 +300,27 @@ func parseToArray(r io.Reader, filename string) ([]changeset.Record, error) {
 		if strings.HasPrefix(line, elementChangeset) {
 			// Create a new changeset.
 			cs := new(changeset.Record)
-			cs.ParseHeader(strings.TrimPrefix(line, elementChangeset))
+			err := cs.ParseHeader(strings.TrimPrefix(line, elementChangeset))
+			if err != nil {
+				pos.column += len(elementChangeset)
+				return nil, nil, pos.parseError(err)
+			}
 			cs.SetFileInfo(path.Base(filename), appVersion)
 			arr = append(arr, *cs)
+			positions = append(positions, pos)
 			continue
 		}
 
 		// If the length of the array is 0, then the first changeset is missing.
 		if len(arr) == 0 {
-			return nil, ErrInvalidFormat
+			return nil, nil, pos.parseError(ErrInvalidFormat)
 		}
 
 		// Determine if the line is a rollback.
 		if strings.HasPrefix(line, elementRollback) {
+			if isGoChangeset(arr[len(arr)-1]) {
+				return nil, nil, pos.parseError(ErrInvalidGoChangeset)
+			}
 			arr[len(arr)-1].AddRollback(strings.TrimPrefix(line, elementRollback))
 			continue
 		}
@@ -94,77 +331,339 @@ func parseToArray(r io.Reader, filename string) ([]changeset.Record, error) {
 			continue
 		}
 
+		// Determine if the line is a tag for the database.
+		if strings.HasPrefix(line, elementTagDatabase) {
+			err = setTag(&arr[len(arr)-1], strings.TrimPrefix(line, elementTagDatabase))
+			if err != nil {
+				pos.column += len(elementTagDatabase)
+				return nil, nil, pos.parseError(err)
+			}
+			continue
+		}
+
+		// Determine if the line is a valid checksum.
+		if strings.HasPrefix(line, elementValidSum) {
+			arr[len(arr)-1].AddValidChecksum(strings.TrimPrefix(line, elementValidSum))
+			continue
+		}
+
+		// Determine if the line is a precondition.
+		if strings.HasPrefix(line, elementPreconditions) ||
+			strings.HasPrefix(line, elementPreconditionSQLCheck) ||
+			strings.HasPrefix(line, elementPreconditionTable) ||
+			strings.HasPrefix(line, elementPreconditionColumn) {
+			err := parsePrecondition(&arr[len(arr)-1], line)
+			if err != nil {
+				return nil, nil, pos.parseError(err)
+			}
+			continue
+		}
+
 		// Determine if the line is comment, ignore it.
 		if strings.HasPrefix(line, "--") {
 			continue
 		}
 
 		// Add the line as a changeset.
+		if isGoChangeset(arr[len(arr)-1]) {
+			return nil, nil, pos.parseError(ErrInvalidGoChangeset)
+		}
 		arr[len(arr)-1].AddChange(line)
 	}
 
-	// Perform a verification check on duplicates.
-	_, err := parseArrayToMap(arr)
-
-	return arr, err
+	return arr, positions, nil
 }
 
-// parseFileToMap will parse a file into a map.
-func parseFileToMap(filename string) (map[string]changeset.Record, error) {
-	f, err := os.Open(filename)
+// readLine will return the next line without the line ending. There is no limit
+// on the length of the line. At the end of the reader, it returns io.EOF.
+func readLine(br *bufio.Reader) (string, error) {
+	line, err := br.ReadString('\n')
+	if err == io.EOF && len(line) > 0 {
+		err = nil
+	}
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	defer f.Close()
 
-	return parseReaderToMap(f, filename)
+	line = strings.TrimSuffix(line, "\n")
+	return strings.TrimSuffix(line, "\r"), nil
 }
 
-// parseReaderToMap will parse a reader to a map.
-func parseReaderToMap(r io.Reader, filename string) (map[string]changeset.Record, error) {
-	arr, err := parseToArray(r, filename)
+// include will parse each of the included files. The errors are at the
+// position of the include unless they are from parsing an included file.
+func (p *parser) include(pos position, files []string) ([]changeset.Record, []position, error) {
+	arr := make([]changeset.Record, 0)
+	positions := make([]position, 0)
+
+	for _, fp := range files {
+		for _, v := range p.files {
+			if v == fp {
+				return nil, nil, pos.parseError(fmt.Errorf("%w: %v", ErrIncludeCycle,
+					strings.Join(append(p.files, fp), " -> ")))
+			}
+		}
+
+		cs, ps, err := p.parseFile(fp)
+		if err != nil {
+			// Return the error from the included file as is.
+			if _, ok := err.(*ParseError); ok {
+				return nil, nil, err
+			}
+			return nil, nil, pos.parseError(err)
+		}
+		arr = append(arr, cs...)
+		positions = append(positions, ps...)
+	}
+
+	return arr, positions, nil
+}
+
+// addProperty will add the property from the text in the format key=value if
+// it's not already defined.
+func (p *parser) addProperty(text string) error {
+	kv := strings.SplitN(text, "=", 2)
+	key := strings.TrimSpace(kv[0])
+	if len(kv) != 2 || len(key) == 0 {
+		return ErrInvalidProperty
+	}
+
+	if _, ok := p.properties[key]; !ok {
+		p.properties[key] = strings.TrimSpace(kv[1])
+	}
+
+	return nil
+}
+
+// glob returns the files that match the include pattern relative to the
+// filename in lexical order. A pattern without wildcards is returned as is so
+// the error is from opening the file.
+func (p *parser) glob(filename, pattern string) ([]string, error) {
+	pattern = strings.TrimSpace(pattern)
+	if len(pattern) == 0 {
+		return nil, ErrInvalidInclude
+	}
+
+	name := path.Join(path.Dir(filename), pattern)
+	if !strings.ContainsAny(pattern, `*?[\`) {
+		return []string{name}, nil
+	}
+
+	matches, err := fs.Glob(p.fsys, name)
 	if err != nil {
 		return nil, err
+	} else if len(matches) == 0 {
+		return nil, fmt.Errorf("%w: %v", ErrIncludeNotFound, pattern)
+	}
+	sort.Strings(matches)
+
+	return matches, nil
+}
+
+// parseIncludeAll returns the directory and whether to include the files in
+// the subdirectories from an includeAll line. The line is the directory
+// followed by optional attributes like recursive:true.
+func parseIncludeAll(line string) (string, bool, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", false, ErrInvalidInclude
+	}
+
+	recursive := false
+	for _, v := range fields[1:] {
+		attr := strings.SplitN(v, ":", 2)
+		if len(attr) != 2 || attr[0] != "recursive" {
+			return "", false, ErrInvalidInclude
+		}
+		b, err := strconv.ParseBool(attr[1])
+		if err != nil {
+			return "", false, ErrInvalidInclude
+		}
+		recursive = b
+	}
+
+	return fields[0], recursive, nil
+}
+
+// dir returns the migration files in the directory relative to the filename
+// in lexical order. The migration files are SQL, YAML, and JSON files.
+func (p *parser) dir(filename, dir string, recursive bool) ([]string, error) {
+	dir = strings.TrimSpace(dir)
+	if len(dir) == 0 {
+		return nil, ErrInvalidInclude
+	}
+
+	root := path.Join(path.Dir(filename), dir)
+	files := make([]string, 0)
+	err := fs.WalkDir(p.fsys, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if name != root && !recursive {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if isMigrationFile(name) {
+			files = append(files, name)
+		}
+
+		return nil
+	})
+
+	return files, err
+}
+
+// isMigrationFile returns true if the file has the extension of a migration
+// file format.
+func isMigrationFile(filename string) bool {
+	switch strings.ToLower(path.Ext(filename)) {
+	case extensionSQL, extensionYAML, extensionYML, extensionJSON:
+		return true
+	}
+
+	return false
+}
+
+// setTag will set the tag of the changeset so the changelog is tagged when the
+// changeset is applied.
+func setTag(cs *changeset.Record, tag string) error {
+	tag = strings.TrimSpace(tag)
+	if len(tag) == 0 || len(strings.Fields(tag)) != 1 || len(cs.Tag) > 0 {
+		return ErrInvalidTag
 	}
 
-	return parseArrayToMap(arr)
+	cs.Tag = tag
+	return nil
+}
+
+// parsePrecondition will add the precondition or the precondition policy from
+// the line to the changeset.
+func parsePrecondition(cs *changeset.Record, line string) error {
+	switch {
+	case strings.HasPrefix(line, elementPreconditionSQLCheck):
+		return cs.AddPrecondition(changeset.PreconditionSQLCheck,
+			strings.TrimPrefix(line, elementPreconditionSQLCheck))
+	case strings.HasPrefix(line, elementPreconditionTable):
+		return cs.AddPrecondition(changeset.PreconditionTableExists,
+			strings.TrimPrefix(line, elementPreconditionTable))
+	case strings.HasPrefix(line, elementPreconditionColumn):
+		return cs.AddPrecondition(changeset.PreconditionColumnExists,
+			strings.TrimPrefix(line, elementPreconditionColumn))
+	}
+
+	return cs.SetPreconditionPolicy(strings.TrimPrefix(line, elementPreconditions))
 }
 
 // parseArrayToMap will convert an array of changesets to a map of changesets.
-func parseArrayToMap(arr []changeset.Record) (map[string]changeset.Record, error) {
+// The duplicates are already found by parseToArray and resolveGoChangesets.
+func parseArrayToMap(arr []changeset.Record) map[string]changeset.Record {
 	m := make(map[string]changeset.Record)
 
 	for _, cs := range arr {
-		id := fmt.Sprintf("%v:%v:%v", cs.Author, cs.ID, cs.Filename)
-		if _, found := m[id]; found {
-			return nil, errors.New("duplicate entry found: " + id)
-		}
-
-		m[id] = cs
+		m[fmt.Sprintf("%v:%v:%v", cs.Author, cs.ID, cs.Filename)] = cs
 	}
 
-	return m, nil
+	return m
 }
 
 // loadChangesets will get the changesets based on the type of migration
-// specified during the creation of the Rove object.
+// specified during the creation of the Rove object. The Go changesets are
+// included and the properties are substituted in the changesets.
 func (r *Rove) loadChangesets() (map[string]changeset.Record, error) {
+	var arr []changeset.Record
+	var err error
+	properties := make(map[string]string)
+
 	// Use the file to get the changesets first.
 	if len(r.file) > 0 {
-		// Get the changesets in a map.
-		m, err := parseFileToMap(r.file)
+		arr, err = parseFileToArray(r.fsys, r.file, properties)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		// Else use the changeset that was passed in.
+		arr, err = parseToArray(r.fsys, strings.NewReader(r.changeset), elementMemory,
+			properties)
 		if err != nil {
 			return nil, err
 		}
+	}
+
+	arr, err = r.resolveGoChangesets(arr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the changesets in a map.
+	m := parseArrayToMap(arr)
 
-		return m, nil
+	for id, cs := range m {
+		err = r.substitute(&cs, properties)
+		if err != nil {
+			return nil, err
+		}
+		m[id] = cs
 	}
 
-	// Else use the changeset that was passed in.
-	arr, err := parseReaderToMap(strings.NewReader(r.changeset), elementMemory)
+	return m, nil
+}
+
+// parseChangesetArray will get the changesets from the file or the changeset
+// string in the order they are defined along with the Go changesets and the
+// properties. The changesets are not filtered and the properties are not
+// substituted in them.
+func (r *Rove) parseChangesetArray() ([]changeset.Record, map[string]string, error) {
+	var arr []changeset.Record
+	var err error
+	properties := make(map[string]string)
+
+	// If a file is specified, use it to build the array.
+	if len(r.file) > 0 {
+		arr, err = parseFileToArray(r.fsys, r.file, properties)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error parsing file: %w", err)
+		}
+	} else {
+		// Else use the changeset that was passed in.
+		arr, err = parseToArray(r.fsys, strings.NewReader(r.changeset), elementMemory,
+			properties)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error on parsing string: %w", err)
+		}
+	}
+
+	arr, err = r.resolveGoChangesets(arr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return arr, properties, nil
+}
+
+// loadChangesetArray will get the changesets from the file or the changeset
+// string in the order they are defined along with the Go changesets. Only the
+// changesets that match the contexts and labels are returned and the
+// properties are substituted in them.
+func (r *Rove) loadChangesetArray() ([]changeset.Record, error) {
+	arr, properties, err := r.parseChangesetArray()
+	if err != nil {
+		return nil, err
+	}
+
+	arr, err = r.filter(arr)
 	if err != nil {
 		return nil, err
 	}
 
+	for i := range arr {
+		err = r.substitute(&arr[i], properties)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return arr, nil
 }
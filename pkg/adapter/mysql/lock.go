@@ -0,0 +1,137 @@
+package mysql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/josephspurrier/rove"
+)
+
+const (
+	lockTableName = "rovechangeloglock"
+)
+
+// dblock contains the changelog lock.
+type dblock struct {
+	LockedBy    string    `db:"lockedby"`
+	LockHost    string    `db:"lockhost"`
+	LockGranted time.Time `db:"lockgranted"`
+}
+
+// initializeLock will create the lock table and the lock row if they don't
+// exist.
+func (m *MySQL) initializeLock() error {
+	_, err := m.DB.Exec(`CREATE TABLE IF NOT EXISTS ` + m.LockTableName + ` (
+	id int(11) NOT NULL,
+	locked tinyint(1) NOT NULL DEFAULT 0,
+	lockgranted datetime DEFAULT NULL,
+	lockedby varchar(191) COLLATE utf8mb4_unicode_ci DEFAULT NULL,
+	lockhost varchar(191) COLLATE utf8mb4_unicode_ci DEFAULT NULL,
+	PRIMARY KEY (id)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.DB.Exec(`INSERT IGNORE INTO ` + m.LockTableName + `
+	(id, locked) VALUES (1, 0)`)
+	return err
+}
+
+// Lock will attempt to acquire the lock for the owner and return true if the
+// lock was acquired.
+func (m *MySQL) Lock(owner, host string, acquired time.Time) (bool, error) {
+	if m.DB == nil {
+		return false, ErrChangelogFailure
+	}
+
+	err := m.initializeLock()
+	if err != nil {
+		return false, err
+	}
+
+	result, err := m.DB.Exec(`
+	UPDATE `+m.LockTableName+`
+	SET locked = 1, lockgranted = ?, lockedby = ?, lockhost = ?
+	WHERE id = 1 AND locked = 0`, acquired, owner, host)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+// TakeLock will give the lock held by the stale owner to the owner and return
+// true if the lock was taken. The owner is unique for each lock so the lock is
+// not taken if it was released or taken by another owner in the meantime.
+func (m *MySQL) TakeLock(stale, owner, host string, acquired time.Time) (bool, error) {
+	if m.DB == nil {
+		return false, ErrChangelogFailure
+	}
+
+	result, err := m.DB.Exec(`
+	UPDATE `+m.LockTableName+`
+	SET lockgranted = ?, lockedby = ?, lockhost = ?
+	WHERE id = 1 AND locked = 1 AND lockedby = ?`, acquired, owner, host, stale)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+// Unlock will release the lock if it's held by the owner.
+func (m *MySQL) Unlock(owner string) error {
+	if m.DB == nil {
+		return ErrChangelogFailure
+	}
+
+	_, err := m.DB.Exec(`
+	UPDATE `+m.LockTableName+`
+	SET locked = 0, lockgranted = NULL, lockedby = NULL, lockhost = NULL
+	WHERE id = 1 AND lockedby = ?`, owner)
+	return err
+}
+
+// LockStatus returns the current lock or nil if the changelog is not locked.
+func (m *MySQL) LockStatus() (*rove.Lock, error) {
+	if m.DB == nil {
+		return nil, ErrChangelogFailure
+	}
+
+	err := m.initializeLock()
+	if err != nil {
+		return nil, err
+	}
+
+	var l dblock
+	err = m.DB.Get(&l, `
+	SELECT lockedby, lockhost, lockgranted FROM `+m.LockTableName+`
+	WHERE id = 1 AND locked = 1`)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &rove.Lock{
+		Owner:    l.LockedBy,
+		Host:     l.LockHost,
+		Acquired: l.LockGranted,
+	}, nil
+}
+
+// ReleaseLock will release the lock regardless of the owner.
+func (m *MySQL) ReleaseLock() error {
+	if m.DB == nil {
+		return ErrChangelogFailure
+	}
+
+	_, err := m.DB.Exec(`
+	UPDATE ` + m.LockTableName + `
+	SET locked = 0, lockgranted = NULL, lockedby = NULL, lockhost = NULL
+	WHERE id = 1`)
+	return err
+}
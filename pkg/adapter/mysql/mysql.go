@@ -22,13 +22,44 @@ const (
 	filename varchar(191) COLLATE utf8mb4_unicode_ci NOT NULL,
 	dateexecuted datetime NOT NULL,
 	orderexecuted int(11) NOT NULL,
-	checksum char(32) COLLATE utf8mb4_unicode_ci NOT NULL,
+	checksum varchar(100) COLLATE utf8mb4_unicode_ci NOT NULL,
 	description varchar(191) COLLATE utf8mb4_unicode_ci NOT NULL,
 	tag varchar(191) COLLATE utf8mb4_unicode_ci DEFAULT NULL UNIQUE,
-	version varchar(191) COLLATE utf8mb4_unicode_ci NOT NULL
+	version varchar(191) COLLATE utf8mb4_unicode_ci NOT NULL,
+	exectype varchar(20) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT 'EXECUTED',
+	contexts varchar(191) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '',
+	labels varchar(191) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '',
+	reruns int(11) NOT NULL DEFAULT 0,
+	lastrerun datetime NULL DEFAULT NULL
 	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
 )
 
+var (
+	// upgrades are the columns added to the changelog table after it was first
+	// released. The columns are added to an existing table on Initialize.
+	upgrades = []struct {
+		column     string
+		definition string
+	}{
+		{"exectype", "varchar(20) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT 'EXECUTED'"},
+		{"contexts", "varchar(191) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT ''"},
+		{"labels", "varchar(191) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT ''"},
+		{"reruns", "int(11) NOT NULL DEFAULT 0"},
+		{"lastrerun", "datetime NULL DEFAULT NULL"},
+	}
+
+	// modifications are the columns changed on the changelog table after it
+	// was first released. The columns are changed on an existing table on
+	// Initialize if the column type doesn't match.
+	modifications = []struct {
+		column     string
+		columnType string
+		definition string
+	}{
+		{"checksum", "varchar(100)", "varchar(100) COLLATE utf8mb4_unicode_ci NOT NULL"},
+	}
+)
+
 var (
 	// ErrChangelogFailure occurs when the connection is not set up properly.
 	ErrChangelogFailure = errors.New("error with changelog setup")
@@ -38,21 +69,27 @@ var (
 
 // dbchangeset contains a single database record change.
 type dbchangeset struct {
-	ID            string    `db:"id"`
-	Author        string    `db:"author"`
-	Filename      string    `db:"filename"`
-	DateExecuted  time.Time `db:"dateexecuted"`
-	OrderExecuted int       `db:"orderexecuted"`
-	Checksum      string    `db:"checksum"`
-	Description   string    `db:"description"`
-	Tag           *string   `db:"tag"`
-	Version       string    `db:"version"`
+	ID            string     `db:"id"`
+	Author        string     `db:"author"`
+	Filename      string     `db:"filename"`
+	DateExecuted  time.Time  `db:"dateexecuted"`
+	OrderExecuted int        `db:"orderexecuted"`
+	Checksum      string     `db:"checksum"`
+	Description   string     `db:"description"`
+	Tag           *string    `db:"tag"`
+	Version       string     `db:"version"`
+	ExecType      string     `db:"exectype"`
+	Contexts      string     `db:"contexts"`
+	Labels        string     `db:"labels"`
+	Reruns        int        `db:"reruns"`
+	LastRerun     *time.Time `db:"lastrerun"`
 }
 
 // MySQL is a MySQL database changelog.
 type MySQL struct {
 	DB              *sqlx.DB
 	TableName       string
+	LockTableName   string
 	InitializeQuery string
 	TransactionFunc func(tx *sql.Tx) rove.Transaction
 }
@@ -66,9 +103,10 @@ func New(c *Connection) (m *MySQL, err error) {
 
 	// Set the default table, create, and transaction.
 	m.TableName = tableName
+	m.LockTableName = lockTableName
 	m.InitializeQuery = createQuery
 	m.TransactionFunc = func(tx *sql.Tx) rove.Transaction {
-		return NewTx(tx)
+		return NewTx(tx, m.TableName)
 	}
 
 	return m, err
@@ -86,9 +124,70 @@ func (m *MySQL) Initialize() (err error) {
 		return err
 	}
 
+	// Upgrade the table.
+	for _, u := range upgrades {
+		err = m.addColumn(u.column, u.definition)
+		if err != nil {
+			return err
+		}
+	}
+	for _, u := range modifications {
+		err = m.modifyColumn(u.column, u.columnType, u.definition)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// modifyColumn will change a column on the changelog table if the column type
+// doesn't match.
+func (m *MySQL) modifyColumn(column, columnType, definition string) error {
+	current := ""
+	err := m.DB.Get(&current, `
+	SELECT COLUMN_TYPE FROM information_schema.COLUMNS
+	WHERE TABLE_SCHEMA = DATABASE()
+	AND TABLE_NAME = ?
+	AND COLUMN_NAME = ?`, m.TableName, column)
+	if err != nil || current == columnType {
+		return err
+	}
+
+	_, err = m.DB.Exec(`ALTER TABLE ` + m.TableName + ` MODIFY COLUMN ` +
+		column + ` ` + definition)
+	return err
+}
+
+// addColumn will add a column to the changelog table if it doesn't exist.
+func (m *MySQL) addColumn(column, definition string) error {
+	count := 0
+	err := m.DB.Get(&count, `
+	SELECT COUNT(*) FROM information_schema.COLUMNS
+	WHERE TABLE_SCHEMA = DATABASE()
+	AND TABLE_NAME = ?
+	AND COLUMN_NAME = ?`, m.TableName, column)
+	if err != nil || count > 0 {
+		return err
+	}
+
+	_, err = m.DB.Exec(`ALTER TABLE ` + m.TableName + ` ADD COLUMN ` +
+		column + ` ` + definition)
+	return err
+}
+
+// ChangelogExists returns true if the changelog table exists so a dry run
+// doesn't have to create it.
+func (m *MySQL) ChangelogExists() (bool, error) {
+	return m.TableExists(m.TableName)
+}
+
+// Dialect returns the MySQL syntax where # is a comment and a backslash is an
+// escape in quotes.
+func (m *MySQL) Dialect() changeset.Dialect {
+	return changeset.DialectMySQL
+}
+
 // ToRecord converts a dbchangeset to a changeset.Record.
 func (m *MySQL) ToRecord(cs dbchangeset) *changeset.Record {
 	tag := ""
@@ -96,6 +195,11 @@ func (m *MySQL) ToRecord(cs dbchangeset) *changeset.Record {
 		tag = *cs.Tag
 	}
 
+	lastRerun := time.Time{}
+	if cs.LastRerun != nil {
+		lastRerun = *cs.LastRerun
+	}
+
 	return &changeset.Record{
 		ID:            cs.ID,
 		Author:        cs.Author,
@@ -106,6 +210,11 @@ func (m *MySQL) ToRecord(cs dbchangeset) *changeset.Record {
 		Description:   cs.Description,
 		Tag:           tag,
 		Version:       cs.Version,
+		ExecType:      cs.ExecType,
+		Contexts:      cs.Contexts,
+		Labels:        cs.Labels,
+		Reruns:        cs.Reruns,
+		LastRerun:     lastRerun,
 	}
 }
 
@@ -165,42 +274,21 @@ func (m *MySQL) Count() (count int, err error) {
 }
 
 // Insert will insert a new record into the database.
-func (m *MySQL) Insert(id, author, filename string, dateexecuted time.Time,
-	count int, checksum, description, version string) error {
+func (m *MySQL) Insert(cs changeset.Record) error {
 	if m.DB == nil {
 		return ErrChangelogFailure
 	}
 
-	_, err := m.DB.Exec(`
-	INSERT INTO `+m.TableName+`
-	(id,author,filename,dateexecuted,orderexecuted,checksum,description,version)
-	VALUES(?,?,?,?,?,?,?,?)`,
-		id, author, filename, dateexecuted, count, checksum, description, version)
-	return err
+	return insert(m.DB, m.TableName, cs)
 }
 
 // Update will update a record from the database.
-func (m *MySQL) Update(id, author, filename string, dateexecuted time.Time,
-	count int, checksum, description, version string) error {
+func (m *MySQL) Update(cs changeset.Record) error {
 	if m.DB == nil {
 		return ErrChangelogFailure
 	}
 
-	_, err := m.DB.Exec(`
-	UPDATE `+m.TableName+`
-	SET
-		dateexecuted = ?,
-		orderexecuted = ?,
-		checksum = ?,
-		description = ?,
-		version = ?
-	WHERE
-		id = ? AND 
-		author = ? AND
-		filename = ?
-	LIMIT 1`,
-		dateexecuted, count, checksum, description, version, id, author, filename)
-	return err
+	return update(m.DB, m.TableName, cs)
 }
 
 // Changesets returns a list of the changesets from the database in ascending
@@ -236,11 +324,7 @@ func (m *MySQL) Delete(id, author, filename string) error {
 		return ErrChangelogFailure
 	}
 
-	// Delete the record.
-	_, err := m.DB.Exec(`
-	DELETE FROM `+m.TableName+`
-	WHERE id = ? AND author = ? AND filename = ? LIMIT 1`, id, author, filename)
-	return err
+	return remove(m.DB, m.TableName, id, author, filename)
 }
 
 // Tag will add a tag to the record.
@@ -267,22 +351,101 @@ func (m *MySQL) Tag(id, author, filename, tag string) error {
 	return err
 }
 
-// Rollback return how many changesets to rollback.
-func (m *MySQL) Rollback(tag string) (int, error) {
+// Rollback returns the changesets applied after the changeset with the tag in
+// descending order of execution.
+func (m *MySQL) Rollback(tag string) ([]changeset.Record, error) {
 	if m.DB == nil {
-		return 0, ErrChangelogFailure
+		return nil, ErrChangelogFailure
 	}
 
-	count := 0
-	err := m.DB.Get(&count, `
-	SELECT count(*) FROM `+m.TableName+`
-	WHERE id > (
-		SELECT id FROM `+m.TableName+` WHERE tag = ?
-	)`, tag)
+	results := make([]dbchangeset, 0)
+	err := m.DB.Select(&results, `
+	SELECT *
+	FROM `+m.TableName+`
+	WHERE orderexecuted > (
+		SELECT orderexecuted FROM `+m.TableName+` WHERE tag = ?
+	)
+	ORDER BY orderexecuted DESC`, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(results) == 0 {
+		return nil, fmt.Errorf("tag not found in database or no rollbacks to perform: %v", tag)
+	}
+
+	// Copy from one struct to another.
+	out := make([]changeset.Record, 0)
+	for _, i := range results {
+		out = append(out, *m.ToRecord(i))
+	}
+
+	return out, nil
+}
+
+// execer is satisfied by both a database and a transaction.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// insert will insert a new record into the changelog table.
+func insert(db execer, table string, cs changeset.Record) error {
+	_, err := db.Exec(`
+	INSERT INTO `+table+`
+	(id,author,filename,dateexecuted,orderexecuted,checksum,description,version,exectype,contexts,labels,reruns,lastrerun,tag)
+	VALUES(?,?,?,?,?,?,?,?,?,?,?,?,?,?)`,
+		cs.ID, cs.Author, cs.Filename, cs.DateExecuted, cs.OrderExecuted,
+		cs.Checksum, cs.Description, cs.Version, cs.ExecType, cs.Contexts,
+		cs.Labels, cs.Reruns, nullTime(cs.LastRerun), nullString(cs.Tag))
+	return err
+}
 
-	if count == 0 {
-		return 0, fmt.Errorf("tag not found in database or no rollbacks to perform: %v", tag)
+// update will update a record in the changelog table.
+func update(db execer, table string, cs changeset.Record) error {
+	_, err := db.Exec(`
+	UPDATE `+table+`
+	SET
+		dateexecuted = ?,
+		orderexecuted = ?,
+		checksum = ?,
+		description = ?,
+		version = ?,
+		exectype = ?,
+		contexts = ?,
+		labels = ?,
+		reruns = ?,
+		lastrerun = ?
+	WHERE
+		id = ? AND 
+		author = ? AND
+		filename = ?
+	LIMIT 1`,
+		cs.DateExecuted, cs.OrderExecuted, cs.Checksum, cs.Description,
+		cs.Version, cs.ExecType, cs.Contexts, cs.Labels, cs.Reruns,
+		nullTime(cs.LastRerun), cs.ID, cs.Author, cs.Filename)
+	return err
+}
+
+// nullTime returns nil if the time is not set so it's stored as NULL.
+func nullTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
 	}
+	return t
+}
 
-	return count, err
+// nullString returns nil if the string is empty so the column is NULL.
+func nullString(s string) interface{} {
+	if len(s) == 0 {
+		return nil
+	}
+	return s
+}
+
+// remove will delete a record from the changelog table.
+func remove(db execer, table, id, author, filename string) error {
+	_, err := db.Exec(`
+	DELETE FROM `+table+`
+	WHERE id = ? AND author = ? AND filename = ? LIMIT 1`, id, author, filename)
+	return err
 }
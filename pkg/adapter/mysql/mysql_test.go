@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/josephspurrier/rove/pkg/adapter/mysql"
+	"github.com/josephspurrier/rove/pkg/changeset"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -47,18 +48,57 @@ func TestErrors(t *testing.T) {
 			_, err := rr.Count()
 			return err
 		}(),
-		rr.Insert("", "", "", time.Now(), 0, "", "", ""),
+		rr.Insert(changeset.Record{}),
 		func() error {
 			_, err := rr.Changesets(false)
 			return err
 		}(),
+		rr.Update(changeset.Record{}),
 		rr.Delete("", "", ""),
 		rr.Tag("", "", "", ""),
 		func() error {
 			_, err := rr.Rollback("")
 			return err
 		}(),
+		func() error {
+			_, err := rr.Lock("", "", time.Now())
+			return err
+		}(),
+		rr.Unlock(""),
+		func() error {
+			_, err := rr.LockStatus()
+			return err
+		}(),
+		rr.ReleaseLock(),
+		func() error {
+			_, err := rr.TakeLock("", "", "", time.Now())
+			return err
+		}(),
+		func() error {
+			_, err := rr.QueryValue("")
+			return err
+		}(),
+		func() error {
+			_, err := rr.TableExists("")
+			return err
+		}(),
+		func() error {
+			_, err := rr.ColumnExists("", "")
+			return err
+		}(),
 	} {
 		assert.Equal(t, mysql.ErrChangelogFailure, v)
 	}
 }
+
+func TestSQL(t *testing.T) {
+	rr := &mysql.MySQL{TableName: "rovechangelog"}
+
+	// Ensure quotes and backslashes are escaped.
+	q := rr.DeleteSQL("1", `jo'seph\`, "success.sql")
+	assert.Contains(t, q, `author = 'jo\'seph\\'`)
+
+	q = rr.InsertSQL(changeset.Record{ID: "1", OrderExecuted: 4})
+	assert.Contains(t, q, "INSERT INTO rovechangelog")
+	assert.Contains(t, q, "CURRENT_TIMESTAMP,4,")
+}
@@ -0,0 +1,49 @@
+package mysql
+
+import (
+	"database/sql"
+)
+
+// QueryValue returns the first column of the first row of the query as a
+// string.
+func (m *MySQL) QueryValue(query string) (string, error) {
+	if m.DB == nil {
+		return "", ErrChangelogFailure
+	}
+
+	var v sql.NullString
+	err := m.DB.QueryRow(query).Scan(&v)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+
+	return v.String, err
+}
+
+// TableExists returns true if the table exists.
+func (m *MySQL) TableExists(table string) (bool, error) {
+	if m.DB == nil {
+		return false, ErrChangelogFailure
+	}
+
+	count := 0
+	err := m.DB.Get(&count, `
+	SELECT COUNT(*) FROM information_schema.TABLES
+	WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?`, table)
+
+	return count > 0, err
+}
+
+// ColumnExists returns true if the column exists on the table.
+func (m *MySQL) ColumnExists(table, column string) (bool, error) {
+	if m.DB == nil {
+		return false, ErrChangelogFailure
+	}
+
+	count := 0
+	err := m.DB.Get(&count, `
+	SELECT COUNT(*) FROM information_schema.COLUMNS
+	WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ?`, table, column)
+
+	return count > 0, err
+}
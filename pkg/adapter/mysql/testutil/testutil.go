@@ -27,7 +27,7 @@ func SetEnv(unique string) {
 	os.Setenv(unique+"DB_USERNAME", "root")
 	os.Setenv(unique+"DB_PASSWORD", "")
 	os.Setenv(unique+"DB_NAME", TestDatabaseName+unique)
-	os.Setenv(unique+"DB_PARAMETER", "parseTime=true&allowNativePasswords=true&multiStatements=true")
+	os.Setenv(unique+"DB_PARAMETER", "parseTime=true&allowNativePasswords=true")
 }
 
 // UnsetEnv will unset the environment variables.
@@ -48,7 +48,7 @@ func Connection(unique string) *mysql.Connection {
 		Username:  "root",
 		Password:  "",
 		Name:      TestDatabaseName + unique,
-		Parameter: "parseTime=true&allowNativePasswords=true&multiStatements=true",
+		Parameter: "parseTime=true&allowNativePasswords=true",
 	}
 }
 
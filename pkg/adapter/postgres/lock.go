@@ -0,0 +1,132 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/josephspurrier/rove"
+)
+
+// dblock contains the changelog lock.
+type dblock struct {
+	LockedBy    string    `db:"lockedby"`
+	LockHost    string    `db:"lockhost"`
+	LockGranted time.Time `db:"lockgranted"`
+}
+
+// initializeLock will create the lock table and the lock row if they don't
+// exist.
+func (p *Postgres) initializeLock() error {
+	_, err := p.DB.Exec(`CREATE TABLE IF NOT EXISTS ` + p.LockTableName + ` (
+	id integer NOT NULL PRIMARY KEY,
+	locked boolean NOT NULL DEFAULT false,
+	lockgranted timestamptz DEFAULT NULL,
+	lockedby varchar(191) DEFAULT NULL,
+	lockhost varchar(191) DEFAULT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.DB.Exec(`INSERT INTO ` + p.LockTableName + `
+	(id, locked) VALUES (1, false) ON CONFLICT (id) DO NOTHING`)
+	return err
+}
+
+// Lock will attempt to acquire the lock for the owner and return true if the
+// lock was acquired.
+func (p *Postgres) Lock(owner, host string, acquired time.Time) (bool, error) {
+	if p.DB == nil {
+		return false, ErrChangelogFailure
+	}
+
+	err := p.initializeLock()
+	if err != nil {
+		return false, err
+	}
+
+	result, err := p.DB.Exec(`
+	UPDATE `+p.LockTableName+`
+	SET locked = true, lockgranted = $1, lockedby = $2, lockhost = $3
+	WHERE id = 1 AND locked = false`, acquired, owner, host)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+// TakeLock will give the lock held by the stale owner to the owner and return
+// true if the lock was taken. The owner is unique for each lock so the lock is
+// not taken if it was released or taken by another owner in the meantime.
+func (p *Postgres) TakeLock(stale, owner, host string, acquired time.Time) (bool, error) {
+	if p.DB == nil {
+		return false, ErrChangelogFailure
+	}
+
+	result, err := p.DB.Exec(`
+	UPDATE `+p.LockTableName+`
+	SET lockgranted = $1, lockedby = $2, lockhost = $3
+	WHERE id = 1 AND locked = true AND lockedby = $4`, acquired, owner, host, stale)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+// Unlock will release the lock if it's held by the owner.
+func (p *Postgres) Unlock(owner string) error {
+	if p.DB == nil {
+		return ErrChangelogFailure
+	}
+
+	_, err := p.DB.Exec(`
+	UPDATE `+p.LockTableName+`
+	SET locked = false, lockgranted = NULL, lockedby = NULL, lockhost = NULL
+	WHERE id = 1 AND lockedby = $1`, owner)
+	return err
+}
+
+// LockStatus returns the current lock or nil if the changelog is not locked.
+func (p *Postgres) LockStatus() (*rove.Lock, error) {
+	if p.DB == nil {
+		return nil, ErrChangelogFailure
+	}
+
+	err := p.initializeLock()
+	if err != nil {
+		return nil, err
+	}
+
+	var l dblock
+	err = p.DB.Get(&l, `
+	SELECT lockedby, lockhost, lockgranted FROM `+p.LockTableName+`
+	WHERE id = 1 AND locked = true`)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &rove.Lock{
+		Owner:    l.LockedBy,
+		Host:     l.LockHost,
+		Acquired: l.LockGranted,
+	}, nil
+}
+
+// ReleaseLock will release the lock regardless of the owner.
+func (p *Postgres) ReleaseLock() error {
+	if p.DB == nil {
+		return ErrChangelogFailure
+	}
+
+	_, err := p.DB.Exec(`
+	UPDATE ` + p.LockTableName + `
+	SET locked = false, lockgranted = NULL, lockedby = NULL, lockhost = NULL
+	WHERE id = 1`)
+	return err
+}
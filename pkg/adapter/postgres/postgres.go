@@ -0,0 +1,455 @@
+// Package postgres is a PostgreSQL changelog adapter.
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/josephspurrier/rove"
+	"github.com/josephspurrier/rove/pkg/changeset"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	tableName     = "rovechangelog"
+	lockTableName = "rovechangeloglock"
+	defaultSchema = "public"
+)
+
+var (
+	// upgrades are the columns added to the changelog table after it was first
+	// released. The columns are added to an existing table on Initialize.
+	upgrades = []struct {
+		column     string
+		definition string
+	}{
+		{"exectype", "varchar(20) NOT NULL DEFAULT 'EXECUTED'"},
+		{"contexts", "varchar(191) NOT NULL DEFAULT ''"},
+		{"labels", "varchar(191) NOT NULL DEFAULT ''"},
+		{"reruns", "integer NOT NULL DEFAULT 0"},
+		{"lastrerun", "timestamptz NULL DEFAULT NULL"},
+	}
+
+	// modifications are the columns changed on the changelog table after it
+	// was first released. The columns are changed on an existing table on
+	// Initialize if the column type doesn't match.
+	modifications = []struct {
+		column     string
+		columnType string
+	}{
+		{"checksum", "character varying(100)"},
+	}
+)
+
+var (
+	// ErrChangelogFailure occurs when the connection is not set up properly.
+	ErrChangelogFailure = errors.New("error with changelog setup")
+	// ErrTransactionFuncMissing occurs when the transaction function is missing.
+	ErrTransactionFuncMissing = errors.New("error transaction func is missing")
+)
+
+// createQuery returns the query to create the changelog table.
+func createQuery(table string) string {
+	return `CREATE TABLE IF NOT EXISTS ` + table + ` (
+	id varchar(191) NOT NULL,
+	author varchar(191) NOT NULL,
+	filename varchar(191) NOT NULL,
+	dateexecuted timestamptz NOT NULL,
+	orderexecuted integer NOT NULL,
+	checksum varchar(100) NOT NULL,
+	description varchar(191) NOT NULL,
+	tag varchar(191) DEFAULT NULL UNIQUE,
+	version varchar(191) NOT NULL,
+	exectype varchar(20) NOT NULL DEFAULT 'EXECUTED',
+	contexts varchar(191) NOT NULL DEFAULT '',
+	labels varchar(191) NOT NULL DEFAULT '',
+	reruns integer NOT NULL DEFAULT 0,
+	lastrerun timestamptz NULL DEFAULT NULL
+	)`
+}
+
+// dbchangeset contains a single database record change.
+type dbchangeset struct {
+	ID            string     `db:"id"`
+	Author        string     `db:"author"`
+	Filename      string     `db:"filename"`
+	DateExecuted  time.Time  `db:"dateexecuted"`
+	OrderExecuted int        `db:"orderexecuted"`
+	Checksum      string     `db:"checksum"`
+	Description   string     `db:"description"`
+	Tag           *string    `db:"tag"`
+	Version       string     `db:"version"`
+	ExecType      string     `db:"exectype"`
+	Contexts      string     `db:"contexts"`
+	Labels        string     `db:"labels"`
+	Reruns        int        `db:"reruns"`
+	LastRerun     *time.Time `db:"lastrerun"`
+}
+
+// Postgres is a PostgreSQL database changelog.
+type Postgres struct {
+	DB              *sqlx.DB
+	TableName       string
+	LockTableName   string
+	InitializeQuery string
+	TransactionFunc func(tx *sql.Tx) rove.Transaction
+}
+
+// New connects to the database and returns an object that satisfies the
+// rove.Changelog interface. The changelog table is created in the schema from
+// the connection or in the public schema if one is not specified.
+func New(c *Connection) (p *Postgres, err error) {
+	// Connect to the database.
+	p = new(Postgres)
+	p.DB, err = c.Connect(true)
+
+	schema := c.Schema
+	if len(schema) == 0 {
+		schema = defaultSchema
+	}
+
+	// Set the default table, create, and transaction.
+	p.TableName = pq.QuoteIdentifier(schema) + "." + pq.QuoteIdentifier(tableName)
+	p.LockTableName = pq.QuoteIdentifier(schema) + "." + pq.QuoteIdentifier(lockTableName)
+	p.InitializeQuery = createQuery(p.TableName)
+	p.TransactionFunc = func(tx *sql.Tx) rove.Transaction {
+		return NewTx(tx, p.TableName)
+	}
+
+	return p, err
+}
+
+// Initialize will create the changelog table or return an error.
+func (p *Postgres) Initialize() (err error) {
+	if p.DB == nil {
+		return ErrChangelogFailure
+	}
+
+	// Create the table.
+	_, err = p.DB.Exec(p.InitializeQuery)
+	if err != nil {
+		return err
+	}
+
+	// Upgrade the table.
+	for _, u := range upgrades {
+		err = p.addColumn(u.column, u.definition)
+		if err != nil {
+			return err
+		}
+	}
+	for _, u := range modifications {
+		err = p.modifyColumn(u.column, u.columnType)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// modifyColumn will change the type of a column on the changelog table if the
+// column type doesn't match.
+func (p *Postgres) modifyColumn(column, columnType string) error {
+	current := ""
+	err := p.DB.Get(&current, `
+	SELECT format_type(atttypid, atttypmod) FROM pg_attribute
+	WHERE attrelid = to_regclass($1) AND attname = $2 AND attnum > 0
+	AND NOT attisdropped`, p.TableName, column)
+	if err != nil || current == columnType {
+		return err
+	}
+
+	_, err = p.DB.Exec(`ALTER TABLE ` + p.TableName + ` ALTER COLUMN ` +
+		column + ` TYPE ` + columnType)
+	return err
+}
+
+// addColumn will add a column to the changelog table if it doesn't exist.
+func (p *Postgres) addColumn(column, definition string) error {
+	_, err := p.DB.Exec(`ALTER TABLE ` + p.TableName + ` ADD COLUMN IF NOT EXISTS ` +
+		column + ` ` + definition)
+	return err
+}
+
+// TransactionalDDL returns true because PostgreSQL schema changes are part of
+// the transaction.
+func (p *Postgres) TransactionalDDL() bool {
+	return true
+}
+
+// ChangelogExists returns true if the changelog table exists so a dry run
+// doesn't have to create it.
+func (p *Postgres) ChangelogExists() (bool, error) {
+	return p.TableExists(p.TableName)
+}
+
+// Dialect returns the PostgreSQL syntax with dollar quotes.
+func (p *Postgres) Dialect() changeset.Dialect {
+	return changeset.DialectPostgres
+}
+
+// ToRecord converts a dbchangeset to a changeset.Record.
+func (p *Postgres) ToRecord(cs dbchangeset) *changeset.Record {
+	tag := ""
+	if cs.Tag != nil {
+		tag = *cs.Tag
+	}
+
+	lastRerun := time.Time{}
+	if cs.LastRerun != nil {
+		lastRerun = *cs.LastRerun
+	}
+
+	return &changeset.Record{
+		ID:            cs.ID,
+		Author:        cs.Author,
+		Filename:      cs.Filename,
+		DateExecuted:  cs.DateExecuted,
+		OrderExecuted: cs.OrderExecuted,
+		Checksum:      cs.Checksum,
+		Description:   cs.Description,
+		Tag:           tag,
+		Version:       cs.Version,
+		ExecType:      cs.ExecType,
+		Contexts:      cs.Contexts,
+		Labels:        cs.Labels,
+		Reruns:        cs.Reruns,
+		LastRerun:     lastRerun,
+	}
+}
+
+// ChangesetApplied returns the checksum from the database if it's found, an
+// error if there was an issue, or nil with no error if it's not
+// found.
+func (p *Postgres) ChangesetApplied(id, author, filename string) (*changeset.Record, error) {
+	if p.DB == nil {
+		return nil, ErrChangelogFailure
+	}
+
+	var cs dbchangeset
+	err := p.DB.Get(&cs, `
+	SELECT * FROM `+p.TableName+`
+	WHERE id = $1
+	AND author = $2
+	AND filename = $3`, id, author, filename)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return p.ToRecord(cs), err
+}
+
+// BeginTx starts a transaction.
+func (p *Postgres) BeginTx() (rove.Transaction, error) {
+	if p.DB == nil {
+		return nil, ErrChangelogFailure
+	}
+
+	if p.TransactionFunc == nil {
+		return nil, ErrTransactionFuncMissing
+	}
+
+	// Begin a transaction.
+	t, err := p.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	return p.TransactionFunc(t), nil
+}
+
+// Count returns the number of changesets in the database.
+func (p *Postgres) Count() (count int, err error) {
+	if p.DB == nil {
+		return 0, ErrChangelogFailure
+	}
+
+	err = p.DB.Get(&count, `SELECT COUNT(*) FROM `+p.TableName)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// Insert will insert a new record into the database.
+func (p *Postgres) Insert(cs changeset.Record) error {
+	if p.DB == nil {
+		return ErrChangelogFailure
+	}
+
+	return insert(p.DB, p.TableName, cs)
+}
+
+// Update will update a record from the database.
+func (p *Postgres) Update(cs changeset.Record) error {
+	if p.DB == nil {
+		return ErrChangelogFailure
+	}
+
+	return update(p.DB, p.TableName, cs)
+}
+
+// Changesets returns a list of the changesets from the database in ascending
+// order (false) or descending order (true).
+func (p *Postgres) Changesets(reverse bool) ([]changeset.Record, error) {
+	if p.DB == nil {
+		return nil, ErrChangelogFailure
+	}
+
+	order := "ASC"
+	if reverse {
+		order = "DESC"
+	}
+
+	results := make([]dbchangeset, 0)
+	err := p.DB.Select(&results, `
+	SELECT *
+	FROM `+p.TableName+`
+	ORDER BY orderexecuted `+order)
+
+	// Copy from one struct to another.
+	out := make([]changeset.Record, 0)
+	for _, i := range results {
+		out = append(out, *p.ToRecord(i))
+	}
+
+	return out, err
+}
+
+// Delete will delete a changeset from the database.
+func (p *Postgres) Delete(id, author, filename string) error {
+	if p.DB == nil {
+		return ErrChangelogFailure
+	}
+
+	return remove(p.DB, p.TableName, id, author, filename)
+}
+
+// Tag will add a tag to the record.
+func (p *Postgres) Tag(id, author, filename, tag string) error {
+	if p.DB == nil {
+		return ErrChangelogFailure
+	}
+
+	_, err := p.DB.Exec(`
+	UPDATE `+p.TableName+`
+	SET tag = $1
+	WHERE id = $2 AND author = $3 AND filename = $4`,
+		tag, id, author, filename)
+
+	pe, ok := err.(*pq.Error)
+	if !ok {
+		return err
+	}
+
+	if pe.Code == "23505" {
+		return fmt.Errorf("tag already found in database: %v", tag)
+	}
+
+	return err
+}
+
+// Rollback returns the changesets applied after the changeset with the tag in
+// descending order of execution.
+func (p *Postgres) Rollback(tag string) ([]changeset.Record, error) {
+	if p.DB == nil {
+		return nil, ErrChangelogFailure
+	}
+
+	results := make([]dbchangeset, 0)
+	err := p.DB.Select(&results, `
+	SELECT *
+	FROM `+p.TableName+`
+	WHERE orderexecuted > (
+		SELECT orderexecuted FROM `+p.TableName+` WHERE tag = $1
+	)
+	ORDER BY orderexecuted DESC`, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(results) == 0 {
+		return nil, fmt.Errorf("tag not found in database or no rollbacks to perform: %v", tag)
+	}
+
+	// Copy from one struct to another.
+	out := make([]changeset.Record, 0)
+	for _, i := range results {
+		out = append(out, *p.ToRecord(i))
+	}
+
+	return out, nil
+}
+
+// execer is satisfied by both a database and a transaction.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// insert will insert a new record into the changelog table.
+func insert(db execer, table string, cs changeset.Record) error {
+	_, err := db.Exec(`
+	INSERT INTO `+table+`
+	(id,author,filename,dateexecuted,orderexecuted,checksum,description,version,exectype,contexts,labels,reruns,lastrerun,tag)
+	VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14)`,
+		cs.ID, cs.Author, cs.Filename, cs.DateExecuted, cs.OrderExecuted,
+		cs.Checksum, cs.Description, cs.Version, cs.ExecType, cs.Contexts,
+		cs.Labels, cs.Reruns, nullTime(cs.LastRerun), nullString(cs.Tag))
+	return err
+}
+
+// update will update a record in the changelog table.
+func update(db execer, table string, cs changeset.Record) error {
+	_, err := db.Exec(`
+	UPDATE `+table+`
+	SET
+		dateexecuted = $1,
+		orderexecuted = $2,
+		checksum = $3,
+		description = $4,
+		version = $5,
+		exectype = $6,
+		contexts = $7,
+		labels = $8,
+		reruns = $9,
+		lastrerun = $10
+	WHERE
+		id = $11 AND
+		author = $12 AND
+		filename = $13`,
+		cs.DateExecuted, cs.OrderExecuted, cs.Checksum, cs.Description,
+		cs.Version, cs.ExecType, cs.Contexts, cs.Labels, cs.Reruns,
+		nullTime(cs.LastRerun), cs.ID, cs.Author, cs.Filename)
+	return err
+}
+
+// nullTime returns nil if the time is not set so it's stored as NULL.
+func nullTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+// nullString returns nil if the string is empty so the column is NULL.
+func nullString(s string) interface{} {
+	if len(s) == 0 {
+		return nil
+	}
+	return s
+}
+
+// remove will delete a record from the changelog table.
+func remove(db execer, table, id, author, filename string) error {
+	_, err := db.Exec(`
+	DELETE FROM `+table+`
+	WHERE id = $1 AND author = $2 AND filename = $3`, id, author, filename)
+	return err
+}
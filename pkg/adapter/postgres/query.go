@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"database/sql"
+)
+
+// QueryValue returns the first column of the first row of the query as a
+// string.
+func (p *Postgres) QueryValue(query string) (string, error) {
+	if p.DB == nil {
+		return "", ErrChangelogFailure
+	}
+
+	var v sql.NullString
+	err := p.DB.QueryRow(query).Scan(&v)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+
+	return v.String, err
+}
+
+// TableExists returns true if the table exists.
+func (p *Postgres) TableExists(table string) (bool, error) {
+	if p.DB == nil {
+		return false, ErrChangelogFailure
+	}
+
+	count := 0
+	err := p.DB.Get(&count, `
+	SELECT COUNT(*) FROM pg_class
+	WHERE oid = to_regclass($1)`, table)
+
+	return count > 0, err
+}
+
+// ColumnExists returns true if the column exists on the table.
+func (p *Postgres) ColumnExists(table, column string) (bool, error) {
+	if p.DB == nil {
+		return false, ErrChangelogFailure
+	}
+
+	count := 0
+	err := p.DB.Get(&count, `
+	SELECT COUNT(*) FROM pg_attribute
+	WHERE attrelid = to_regclass($1) AND attname = $2 AND attnum > 0
+	AND NOT attisdropped`, table, column)
+
+	return count > 0, err
+}
@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"database/sql"
+
+	"github.com/josephspurrier/rove/pkg/changeset"
+)
+
+// Tx is a database transaction.
+type Tx struct {
+	db        *sql.Tx
+	tableName string
+}
+
+// NewTx creates a new database transaction. The table name is the changelog
+// table that is written to in the same transaction as the changeset.
+func NewTx(tx *sql.Tx, tableName string) *Tx {
+	return &Tx{
+		db:        tx,
+		tableName: tableName,
+	}
+}
+
+// Commit will commit changes to the database or return an error.
+func (t *Tx) Commit() error {
+	return t.db.Commit()
+}
+
+// Rollback will rollback changes to the database or return an error.
+func (t *Tx) Rollback() error {
+	return t.db.Rollback()
+}
+
+// Exec will run a query on the database.
+func (t *Tx) Exec(query string) error {
+	_, err := t.db.Exec(query)
+	return err
+}
+
+// SQLTx returns the database transaction.
+func (t *Tx) SQLTx() *sql.Tx {
+	return t.db
+}
+
+// Insert will insert a new record into the changelog as part of the
+// transaction.
+func (t *Tx) Insert(cs changeset.Record) error {
+	return insert(t.db, t.tableName, cs)
+}
+
+// Update will update a record in the changelog as part of the transaction.
+func (t *Tx) Update(cs changeset.Record) error {
+	return update(t.db, t.tableName, cs)
+}
+
+// Delete will delete a changeset from the changelog as part of the
+// transaction.
+func (t *Tx) Delete(id, author, filename string) error {
+	return remove(t.db, t.tableName, id, author, filename)
+}
@@ -0,0 +1,60 @@
+package sqlite
+
+import (
+	"github.com/josephspurrier/rove/pkg/env"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3" // SQLite driver.
+)
+
+const (
+	// Memory is the database name for an in-memory database.
+	Memory = ":memory:"
+)
+
+// Connection holds the details for the SQLite connection.
+type Connection struct {
+	Name      string `json:"Database" env:"DB_NAME"`
+	Parameter string `json:"Parameter" env:"DB_PARAMETER"`
+}
+
+// NewConnection returns the info required to make a connection to a SQLite
+// database from environment variables. The optional prefix is used when reading
+// environment variables.
+func NewConnection(prefix string) (*Connection, error) {
+	dbc := new(Connection)
+
+	// Load the struct from environment variables.
+	err := env.Unmarshal(dbc, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return dbc, nil
+}
+
+// Connect to the database.
+func (c Connection) Connect() (*sqlx.DB, error) {
+	// Connect to database and verify with a ping.
+	db, err := sqlx.Connect("sqlite3", c.DSN())
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite only allows a single writer and each connection to an in-memory
+	// database is a separate database so only use one connection.
+	db.SetMaxOpenConns(1)
+
+	return db, nil
+}
+
+// DSN returns the Data Source Name.
+func (c Connection) DSN() string {
+	// Example:
+	// /var/lib/app/main.db?_foreign_keys=true
+	if len(c.Parameter) > 0 {
+		return c.Name + "?" + c.Parameter
+	}
+
+	return c.Name
+}
@@ -0,0 +1,136 @@
+package sqlite
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/josephspurrier/rove"
+)
+
+const (
+	lockTableName = "rovechangeloglock"
+)
+
+// dblock contains the changelog lock.
+type dblock struct {
+	LockedBy    string    `db:"lockedby"`
+	LockHost    string    `db:"lockhost"`
+	LockGranted time.Time `db:"lockgranted"`
+}
+
+// initializeLock will create the lock table and the lock row if they don't
+// exist.
+func (s *SQLite) initializeLock() error {
+	_, err := s.DB.Exec(`CREATE TABLE IF NOT EXISTS ` + s.LockTableName + ` (
+	id INTEGER NOT NULL PRIMARY KEY,
+	locked INTEGER NOT NULL DEFAULT 0,
+	lockgranted DATETIME DEFAULT NULL,
+	lockedby TEXT DEFAULT NULL,
+	lockhost TEXT DEFAULT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.DB.Exec(`INSERT OR IGNORE INTO ` + s.LockTableName + `
+	(id, locked) VALUES (1, 0)`)
+	return err
+}
+
+// Lock will attempt to acquire the lock for the owner and return true if the
+// lock was acquired.
+func (s *SQLite) Lock(owner, host string, acquired time.Time) (bool, error) {
+	if s.DB == nil {
+		return false, ErrChangelogFailure
+	}
+
+	err := s.initializeLock()
+	if err != nil {
+		return false, err
+	}
+
+	result, err := s.DB.Exec(`
+	UPDATE `+s.LockTableName+`
+	SET locked = 1, lockgranted = ?, lockedby = ?, lockhost = ?
+	WHERE id = 1 AND locked = 0`, acquired, owner, host)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+// TakeLock will give the lock held by the stale owner to the owner and return
+// true if the lock was taken. The owner is unique for each lock so the lock is
+// not taken if it was released or taken by another owner in the meantime.
+func (s *SQLite) TakeLock(stale, owner, host string, acquired time.Time) (bool, error) {
+	if s.DB == nil {
+		return false, ErrChangelogFailure
+	}
+
+	result, err := s.DB.Exec(`
+	UPDATE `+s.LockTableName+`
+	SET lockgranted = ?, lockedby = ?, lockhost = ?
+	WHERE id = 1 AND locked = 1 AND lockedby = ?`, acquired, owner, host, stale)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+// Unlock will release the lock if it's held by the owner.
+func (s *SQLite) Unlock(owner string) error {
+	if s.DB == nil {
+		return ErrChangelogFailure
+	}
+
+	_, err := s.DB.Exec(`
+	UPDATE `+s.LockTableName+`
+	SET locked = 0, lockgranted = NULL, lockedby = NULL, lockhost = NULL
+	WHERE id = 1 AND lockedby = ?`, owner)
+	return err
+}
+
+// LockStatus returns the current lock or nil if the changelog is not locked.
+func (s *SQLite) LockStatus() (*rove.Lock, error) {
+	if s.DB == nil {
+		return nil, ErrChangelogFailure
+	}
+
+	err := s.initializeLock()
+	if err != nil {
+		return nil, err
+	}
+
+	var l dblock
+	err = s.DB.Get(&l, `
+	SELECT lockedby, lockhost, lockgranted FROM `+s.LockTableName+`
+	WHERE id = 1 AND locked = 1`)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &rove.Lock{
+		Owner:    l.LockedBy,
+		Host:     l.LockHost,
+		Acquired: l.LockGranted,
+	}, nil
+}
+
+// ReleaseLock will release the lock regardless of the owner.
+func (s *SQLite) ReleaseLock() error {
+	if s.DB == nil {
+		return ErrChangelogFailure
+	}
+
+	_, err := s.DB.Exec(`
+	UPDATE ` + s.LockTableName + `
+	SET locked = 0, lockgranted = NULL, lockedby = NULL, lockhost = NULL
+	WHERE id = 1`)
+	return err
+}
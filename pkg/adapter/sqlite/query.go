@@ -0,0 +1,49 @@
+package sqlite
+
+import (
+	"database/sql"
+)
+
+// QueryValue returns the first column of the first row of the query as a
+// string.
+func (s *SQLite) QueryValue(query string) (string, error) {
+	if s.DB == nil {
+		return "", ErrChangelogFailure
+	}
+
+	var v sql.NullString
+	err := s.DB.QueryRow(query).Scan(&v)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+
+	return v.String, err
+}
+
+// TableExists returns true if the table exists.
+func (s *SQLite) TableExists(table string) (bool, error) {
+	if s.DB == nil {
+		return false, ErrChangelogFailure
+	}
+
+	count := 0
+	err := s.DB.Get(&count, `
+	SELECT COUNT(*) FROM sqlite_master
+	WHERE type IN ('table', 'view') AND name = ?`, table)
+
+	return count > 0, err
+}
+
+// ColumnExists returns true if the column exists on the table.
+func (s *SQLite) ColumnExists(table, column string) (bool, error) {
+	if s.DB == nil {
+		return false, ErrChangelogFailure
+	}
+
+	count := 0
+	err := s.DB.Get(&count, `
+	SELECT COUNT(*) FROM pragma_table_info(?)
+	WHERE name = ?`, table, column)
+
+	return count > 0, err
+}
@@ -0,0 +1,73 @@
+package sqlite
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/josephspurrier/rove/pkg/changeset"
+)
+
+// InsertSQL returns the SQL to insert a record into the changelog table.
+func (s *SQLite) InsertSQL(cs changeset.Record) string {
+	return fmt.Sprintf(`INSERT INTO %v
+	(id,author,filename,dateexecuted,orderexecuted,checksum,description,version,exectype,contexts,labels,reruns,lastrerun,tag)
+	VALUES(%v,%v,%v,CURRENT_TIMESTAMP,%v,%v,%v,%v,%v,%v,%v,%v,%v,%v)`, s.TableName,
+		quote(cs.ID), quote(cs.Author), quote(cs.Filename), cs.OrderExecuted,
+		quote(cs.Checksum), quote(cs.Description), quote(cs.Version),
+		quote(cs.ExecType), quote(cs.Contexts), quote(cs.Labels), cs.Reruns,
+		timestamp(cs.LastRerun), nullQuote(cs.Tag))
+}
+
+// UpdateSQL returns the SQL to update a record in the changelog table.
+func (s *SQLite) UpdateSQL(cs changeset.Record) string {
+	return fmt.Sprintf(`UPDATE %v
+	SET
+		dateexecuted = CURRENT_TIMESTAMP,
+		orderexecuted = %v,
+		checksum = %v,
+		description = %v,
+		version = %v,
+		exectype = %v,
+		contexts = %v,
+		labels = %v,
+		reruns = %v,
+		lastrerun = %v
+	WHERE
+		id = %v AND
+		author = %v AND
+		filename = %v`, s.TableName, cs.OrderExecuted, quote(cs.Checksum),
+		quote(cs.Description), quote(cs.Version), quote(cs.ExecType),
+		quote(cs.Contexts), quote(cs.Labels), cs.Reruns,
+		timestamp(cs.LastRerun), quote(cs.ID), quote(cs.Author),
+		quote(cs.Filename))
+}
+
+// DeleteSQL returns the SQL to delete a record from the changelog table.
+func (s *SQLite) DeleteSQL(id, author, filename string) string {
+	return fmt.Sprintf(`DELETE FROM %v
+	WHERE id = %v AND author = %v AND filename = %v`, s.TableName, quote(id),
+		quote(author), quote(filename))
+}
+
+// timestamp returns NULL if the time is not set or the current timestamp.
+func timestamp(t time.Time) string {
+	if t.IsZero() {
+		return "NULL"
+	}
+	return "CURRENT_TIMESTAMP"
+}
+
+// nullQuote returns NULL if the value is empty or the value as a string
+// literal.
+func nullQuote(s string) string {
+	if len(s) == 0 {
+		return "NULL"
+	}
+	return quote(s)
+}
+
+// quote returns the value as a string literal.
+func quote(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
@@ -0,0 +1,413 @@
+// Package sqlite is a SQLite changelog adapter.
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/josephspurrier/rove"
+	"github.com/josephspurrier/rove/pkg/changeset"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	tableName   = "rovechangelog"
+	createQuery = `CREATE TABLE IF NOT EXISTS ` + tableName + ` (
+	id TEXT NOT NULL,
+	author TEXT NOT NULL,
+	filename TEXT NOT NULL,
+	dateexecuted DATETIME NOT NULL,
+	orderexecuted INTEGER NOT NULL,
+	checksum TEXT NOT NULL,
+	description TEXT NOT NULL,
+	tag TEXT DEFAULT NULL UNIQUE,
+	version TEXT NOT NULL,
+	exectype TEXT NOT NULL DEFAULT 'EXECUTED',
+	contexts TEXT NOT NULL DEFAULT '',
+	labels TEXT NOT NULL DEFAULT '',
+	reruns INTEGER NOT NULL DEFAULT 0,
+	lastrerun DATETIME NULL DEFAULT NULL
+	)`
+)
+
+var (
+	// upgrades are the columns added to the changelog table after it was first
+	// released. The columns are added to an existing table on Initialize.
+	upgrades = []struct {
+		column     string
+		definition string
+	}{
+		{"exectype", "TEXT NOT NULL DEFAULT 'EXECUTED'"},
+		{"contexts", "TEXT NOT NULL DEFAULT ''"},
+		{"labels", "TEXT NOT NULL DEFAULT ''"},
+		{"reruns", "INTEGER NOT NULL DEFAULT 0"},
+		{"lastrerun", "DATETIME NULL DEFAULT NULL"},
+	}
+)
+
+var (
+	// ErrChangelogFailure occurs when the connection is not set up properly.
+	ErrChangelogFailure = errors.New("error with changelog setup")
+	// ErrTransactionFuncMissing occurs when the transaction function is missing.
+	ErrTransactionFuncMissing = errors.New("error transaction func is missing")
+)
+
+// dbchangeset contains a single database record change.
+type dbchangeset struct {
+	ID            string     `db:"id"`
+	Author        string     `db:"author"`
+	Filename      string     `db:"filename"`
+	DateExecuted  time.Time  `db:"dateexecuted"`
+	OrderExecuted int        `db:"orderexecuted"`
+	Checksum      string     `db:"checksum"`
+	Description   string     `db:"description"`
+	Tag           *string    `db:"tag"`
+	Version       string     `db:"version"`
+	ExecType      string     `db:"exectype"`
+	Contexts      string     `db:"contexts"`
+	Labels        string     `db:"labels"`
+	Reruns        int        `db:"reruns"`
+	LastRerun     *time.Time `db:"lastrerun"`
+}
+
+// SQLite is a SQLite database changelog.
+type SQLite struct {
+	DB              *sqlx.DB
+	TableName       string
+	LockTableName   string
+	InitializeQuery string
+	TransactionFunc func(tx *sql.Tx) rove.Transaction
+}
+
+// New connects to the database and returns an object that satisfies the
+// rove.Changelog interface.
+func New(c *Connection) (s *SQLite, err error) {
+	// Connect to the database.
+	s = new(SQLite)
+	s.DB, err = c.Connect()
+
+	// Set the default table, create, and transaction.
+	s.TableName = tableName
+	s.LockTableName = lockTableName
+	s.InitializeQuery = createQuery
+	s.TransactionFunc = func(tx *sql.Tx) rove.Transaction {
+		return NewTx(tx, s.TableName)
+	}
+
+	return s, err
+}
+
+// Initialize will create the changelog table or return an error.
+func (s *SQLite) Initialize() (err error) {
+	if s.DB == nil {
+		return ErrChangelogFailure
+	}
+
+	// Create the table.
+	_, err = s.DB.Exec(s.InitializeQuery)
+	if err != nil {
+		return err
+	}
+
+	// Upgrade the table.
+	for _, u := range upgrades {
+		err = s.addColumn(u.column, u.definition)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addColumn will add a column to the changelog table if it doesn't exist.
+func (s *SQLite) addColumn(column, definition string) error {
+	count := 0
+	err := s.DB.Get(&count, `
+	SELECT COUNT(*) FROM pragma_table_info(?)
+	WHERE name = ?`, s.TableName, column)
+	if err != nil || count > 0 {
+		return err
+	}
+
+	_, err = s.DB.Exec(`ALTER TABLE ` + s.TableName + ` ADD COLUMN ` +
+		column + ` ` + definition)
+	return err
+}
+
+// TransactionalDDL returns true because SQLite schema changes are part of the
+// transaction.
+func (s *SQLite) TransactionalDDL() bool {
+	return true
+}
+
+// ChangelogExists returns true if the changelog table exists so a dry run
+// doesn't have to create it.
+func (s *SQLite) ChangelogExists() (bool, error) {
+	return s.TableExists(s.TableName)
+}
+
+// ToRecord converts a dbchangeset to a changeset.Record.
+func (s *SQLite) ToRecord(cs dbchangeset) *changeset.Record {
+	tag := ""
+	if cs.Tag != nil {
+		tag = *cs.Tag
+	}
+
+	lastRerun := time.Time{}
+	if cs.LastRerun != nil {
+		lastRerun = *cs.LastRerun
+	}
+
+	return &changeset.Record{
+		ID:            cs.ID,
+		Author:        cs.Author,
+		Filename:      cs.Filename,
+		DateExecuted:  cs.DateExecuted,
+		OrderExecuted: cs.OrderExecuted,
+		Checksum:      cs.Checksum,
+		Description:   cs.Description,
+		Tag:           tag,
+		Version:       cs.Version,
+		ExecType:      cs.ExecType,
+		Contexts:      cs.Contexts,
+		Labels:        cs.Labels,
+		Reruns:        cs.Reruns,
+		LastRerun:     lastRerun,
+	}
+}
+
+// ChangesetApplied returns the checksum from the database if it's found, an
+// error if there was an issue, or nil with no error if it's not
+// found.
+func (s *SQLite) ChangesetApplied(id, author, filename string) (*changeset.Record, error) {
+	if s.DB == nil {
+		return nil, ErrChangelogFailure
+	}
+
+	var cs dbchangeset
+	err := s.DB.Get(&cs, `
+	SELECT * FROM `+s.TableName+`
+	WHERE id = ?
+	AND author = ?
+	AND filename = ?`, id, author, filename)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return s.ToRecord(cs), err
+}
+
+// BeginTx starts a transaction.
+func (s *SQLite) BeginTx() (rove.Transaction, error) {
+	if s.DB == nil {
+		return nil, ErrChangelogFailure
+	}
+
+	if s.TransactionFunc == nil {
+		return nil, ErrTransactionFuncMissing
+	}
+
+	// Begin a transaction.
+	t, err := s.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.TransactionFunc(t), nil
+}
+
+// Count returns the number of changesets in the database.
+func (s *SQLite) Count() (count int, err error) {
+	if s.DB == nil {
+		return 0, ErrChangelogFailure
+	}
+
+	err = s.DB.Get(&count, `SELECT COUNT(*) FROM `+s.TableName)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// Insert will insert a new record into the database.
+func (s *SQLite) Insert(cs changeset.Record) error {
+	if s.DB == nil {
+		return ErrChangelogFailure
+	}
+
+	return insert(s.DB, s.TableName, cs)
+}
+
+// Update will update a record from the database.
+func (s *SQLite) Update(cs changeset.Record) error {
+	if s.DB == nil {
+		return ErrChangelogFailure
+	}
+
+	return update(s.DB, s.TableName, cs)
+}
+
+// Changesets returns a list of the changesets from the database in ascending
+// order (false) or descending order (true).
+func (s *SQLite) Changesets(reverse bool) ([]changeset.Record, error) {
+	if s.DB == nil {
+		return nil, ErrChangelogFailure
+	}
+
+	order := "ASC"
+	if reverse {
+		order = "DESC"
+	}
+
+	results := make([]dbchangeset, 0)
+	err := s.DB.Select(&results, `
+	SELECT *
+	FROM `+s.TableName+`
+	ORDER BY orderexecuted `+order)
+
+	// Copy from one struct to another.
+	out := make([]changeset.Record, 0)
+	for _, i := range results {
+		out = append(out, *s.ToRecord(i))
+	}
+
+	return out, err
+}
+
+// Delete will delete a changeset from the database.
+func (s *SQLite) Delete(id, author, filename string) error {
+	if s.DB == nil {
+		return ErrChangelogFailure
+	}
+
+	return remove(s.DB, s.TableName, id, author, filename)
+}
+
+// Tag will add a tag to the record.
+func (s *SQLite) Tag(id, author, filename, tag string) error {
+	if s.DB == nil {
+		return ErrChangelogFailure
+	}
+
+	_, err := s.DB.Exec(`
+	UPDATE `+s.TableName+`
+	SET tag=?
+	WHERE id = ? AND author = ? AND filename = ?`,
+		tag, id, author, filename)
+
+	se, ok := err.(sqlite3.Error)
+	if !ok {
+		return err
+	}
+
+	if se.ExtendedCode == sqlite3.ErrConstraintUnique {
+		return fmt.Errorf("tag already found in database: %v", tag)
+	}
+
+	return err
+}
+
+// Rollback returns the changesets applied after the changeset with the tag in
+// descending order of execution.
+func (s *SQLite) Rollback(tag string) ([]changeset.Record, error) {
+	if s.DB == nil {
+		return nil, ErrChangelogFailure
+	}
+
+	results := make([]dbchangeset, 0)
+	err := s.DB.Select(&results, `
+	SELECT *
+	FROM `+s.TableName+`
+	WHERE orderexecuted > (
+		SELECT orderexecuted FROM `+s.TableName+` WHERE tag = ?
+	)
+	ORDER BY orderexecuted DESC`, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(results) == 0 {
+		return nil, fmt.Errorf("tag not found in database or no rollbacks to perform: %v", tag)
+	}
+
+	// Copy from one struct to another.
+	out := make([]changeset.Record, 0)
+	for _, i := range results {
+		out = append(out, *s.ToRecord(i))
+	}
+
+	return out, nil
+}
+
+// execer is satisfied by both a database and a transaction.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// insert will insert a new record into the changelog table.
+func insert(db execer, table string, cs changeset.Record) error {
+	_, err := db.Exec(`
+	INSERT INTO `+table+`
+	(id,author,filename,dateexecuted,orderexecuted,checksum,description,version,exectype,contexts,labels,reruns,lastrerun,tag)
+	VALUES(?,?,?,?,?,?,?,?,?,?,?,?,?,?)`,
+		cs.ID, cs.Author, cs.Filename, cs.DateExecuted, cs.OrderExecuted,
+		cs.Checksum, cs.Description, cs.Version, cs.ExecType, cs.Contexts,
+		cs.Labels, cs.Reruns, nullTime(cs.LastRerun), nullString(cs.Tag))
+	return err
+}
+
+// update will update a record in the changelog table.
+func update(db execer, table string, cs changeset.Record) error {
+	_, err := db.Exec(`
+	UPDATE `+table+`
+	SET
+		dateexecuted = ?,
+		orderexecuted = ?,
+		checksum = ?,
+		description = ?,
+		version = ?,
+		exectype = ?,
+		contexts = ?,
+		labels = ?,
+		reruns = ?,
+		lastrerun = ?
+	WHERE
+		id = ? AND
+		author = ? AND
+		filename = ?`,
+		cs.DateExecuted, cs.OrderExecuted, cs.Checksum, cs.Description,
+		cs.Version, cs.ExecType, cs.Contexts, cs.Labels, cs.Reruns,
+		nullTime(cs.LastRerun), cs.ID, cs.Author, cs.Filename)
+	return err
+}
+
+// nullTime returns nil if the time is not set so it's stored as NULL.
+func nullTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+// nullString returns nil if the string is empty so the column is NULL.
+func nullString(s string) interface{} {
+	if len(s) == 0 {
+		return nil
+	}
+	return s
+}
+
+// remove will delete a record from the changelog table.
+func remove(db execer, table, id, author, filename string) error {
+	_, err := db.Exec(`
+	DELETE FROM `+table+`
+	WHERE id = ? AND author = ? AND filename = ?`, id, author, filename)
+	return err
+}
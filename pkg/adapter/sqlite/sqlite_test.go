@@ -0,0 +1,218 @@
+package sqlite_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/josephspurrier/rove/pkg/adapter/sqlite"
+	"github.com/josephspurrier/rove/pkg/changeset"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnection(t *testing.T) {
+	c := sqlite.Connection{}
+	c.Name = "test.db"
+	c.Parameter = "_foreign_keys=true"
+
+	// Test with parameters.
+	dsn := c.DSN()
+	assert.Equal(t, "test.db?_foreign_keys=true", dsn)
+
+	// Test without parameters.
+	c.Parameter = ""
+	dsn = c.DSN()
+	assert.Equal(t, "test.db", dsn)
+}
+
+func TestErrors(t *testing.T) {
+	rr := new(sqlite.SQLite)
+	for _, v := range []error{
+		rr.Initialize(),
+		func() error {
+			_, err := rr.ChangesetApplied("", "", "")
+			return err
+		}(),
+		func() error {
+			_, err := rr.BeginTx()
+			return err
+		}(),
+		func() error {
+			_, err := rr.Count()
+			return err
+		}(),
+		rr.Insert(changeset.Record{}),
+		func() error {
+			_, err := rr.Changesets(false)
+			return err
+		}(),
+		rr.Update(changeset.Record{}),
+		rr.Delete("", "", ""),
+		rr.Tag("", "", "", ""),
+		func() error {
+			_, err := rr.Rollback("")
+			return err
+		}(),
+		func() error {
+			_, err := rr.Lock("", "", time.Now())
+			return err
+		}(),
+		rr.Unlock(""),
+		func() error {
+			_, err := rr.LockStatus()
+			return err
+		}(),
+		rr.ReleaseLock(),
+		func() error {
+			_, err := rr.TakeLock("", "", "", time.Now())
+			return err
+		}(),
+		func() error {
+			_, err := rr.QueryValue("")
+			return err
+		}(),
+		func() error {
+			_, err := rr.TableExists("")
+			return err
+		}(),
+		func() error {
+			_, err := rr.ColumnExists("", "")
+			return err
+		}(),
+	} {
+		assert.Equal(t, sqlite.ErrChangelogFailure, v)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	s, err := sqlite.New(&sqlite.Connection{Name: sqlite.Memory})
+	assert.Nil(t, err)
+
+	// Create the changelog.
+	err = s.Initialize()
+	assert.Nil(t, err)
+
+	// Add a record.
+	err = s.Insert(changeset.Record{
+		ID:            "1",
+		Author:        "josephspurrier",
+		Filename:      "success.sql",
+		DateExecuted:  time.Now(),
+		OrderExecuted: 1,
+		Checksum:      "checksum",
+		ExecType:      changeset.ExecTypeExecuted,
+	})
+	assert.Nil(t, err)
+
+	// Read the record back.
+	cs, err := s.ChangesetApplied("1", "josephspurrier", "success.sql")
+	assert.Nil(t, err)
+	assert.Equal(t, "checksum", cs.Checksum)
+	assert.Equal(t, 1, cs.OrderExecuted)
+	assert.Equal(t, changeset.ExecTypeExecuted, cs.ExecType)
+
+	// Initialize again to ensure the upgrades can run on an existing table.
+	err = s.Initialize()
+	assert.Nil(t, err)
+
+	// Tag the record.
+	err = s.Tag("1", "josephspurrier", "success.sql", "jas1")
+	assert.Nil(t, err)
+
+	// Add and tag another record with the same tag.
+	err = s.Insert(changeset.Record{
+		ID:            "2",
+		Author:        "josephspurrier",
+		Filename:      "success.sql",
+		DateExecuted:  time.Now(),
+		OrderExecuted: 2,
+		Checksum:      "checksum",
+		ExecType:      changeset.ExecTypeExecuted,
+	})
+	assert.Nil(t, err)
+	err = s.Tag("2", "josephspurrier", "success.sql", "jas1")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "tag already found")
+
+	// Determine the changesets to rollback.
+	records, err := s.Rollback("jas1")
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(records)) {
+		assert.Equal(t, "2", records[0].ID)
+	}
+
+	// Remove the record.
+	err = s.Delete("2", "josephspurrier", "success.sql")
+	assert.Nil(t, err)
+	count, err := s.Count()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestLock(t *testing.T) {
+	s, err := sqlite.New(&sqlite.Connection{Name: sqlite.Memory})
+	assert.Nil(t, err)
+
+	// Ensure the changelog is not locked.
+	l, err := s.LockStatus()
+	assert.Nil(t, err)
+	assert.Nil(t, l)
+
+	// Acquire the lock.
+	ok, err := s.Lock("owner1", "host1", time.Now())
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	// Fail to acquire the lock with another owner.
+	ok, err = s.Lock("owner2", "host2", time.Now())
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	// Ensure the lock is held by the first owner.
+	l, err = s.LockStatus()
+	assert.Nil(t, err)
+	assert.Equal(t, "owner1", l.Owner)
+	assert.Equal(t, "host1", l.Host)
+
+	// Another owner cannot unlock.
+	err = s.Unlock("owner2")
+	assert.Nil(t, err)
+	l, err = s.LockStatus()
+	assert.Nil(t, err)
+	assert.NotNil(t, l)
+
+	// Unlock with the owner.
+	err = s.Unlock("owner1")
+	assert.Nil(t, err)
+	l, err = s.LockStatus()
+	assert.Nil(t, err)
+	assert.Nil(t, l)
+
+	// Take the lock only if it's still held by the stale owner.
+	ok, err = s.Lock("owner1", "host1", time.Now())
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	ok, err = s.TakeLock("owner1", "owner2", "host2", time.Now())
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	ok, err = s.TakeLock("owner1", "owner3", "host3", time.Now())
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	l, err = s.LockStatus()
+	assert.Nil(t, err)
+	assert.Equal(t, "owner2", l.Owner)
+	err = s.Unlock("owner2")
+	assert.Nil(t, err)
+	ok, err = s.TakeLock("owner2", "owner3", "host3", time.Now())
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	// Release the lock regardless of the owner.
+	ok, err = s.Lock("owner2", "host2", time.Now())
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	err = s.ReleaseLock()
+	assert.Nil(t, err)
+	l, err = s.LockStatus()
+	assert.Nil(t, err)
+	assert.Nil(t, l)
+}
@@ -4,10 +4,28 @@ package changeset
 import (
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 )
 
+const (
+	// ExecTypeExecuted is when the changeset was applied and recorded.
+	ExecTypeExecuted = "EXECUTED"
+	// ExecTypePending is when the changeset was recorded before it was applied,
+	// but the changeset was not confirmed as applied.
+	ExecTypePending = "PENDING"
+	// ExecTypeRollbackPending is when the rollback of the changeset was started,
+	// but the changeset was not confirmed as removed.
+	ExecTypeRollbackPending = "PENDING_ROLLBACK"
+	// ExecTypeReran is when the changeset was applied again because it runs
+	// on change or runs always.
+	ExecTypeReran = "RERAN"
+	// ExecTypeMarkRan is when the changeset was recorded without being applied
+	// because a precondition failed.
+	ExecTypeMarkRan = "MARK_RAN"
+)
+
 var (
 	// ErrInvalidHeader is when the changeset header is invalid.
 	ErrInvalidHeader = errors.New("invalid changeset header")
@@ -15,29 +33,87 @@ var (
 
 // Record is a changeset.
 type Record struct {
-	ID            string
-	Author        string
-	Filename      string
-	DateExecuted  time.Time
-	OrderExecuted int
-	Checksum      string
-	Description   string
-	Tag           string
-	Version       string
-
-	change   []string
-	rollback []string
+	ID              string
+	Author          string
+	Filename        string
+	DateExecuted    time.Time
+	OrderExecuted   int
+	Checksum        string
+	Description     string
+	Tag             string
+	Version         string
+	ExecType        string
+	Contexts        string
+	Labels          string
+	Reruns          int
+	LastRerun       time.Time
+	RunOnChange     bool
+	RunAlways       bool
+	SplitStatements bool
+	EndDelimiter    string
+	OnFail          string
+	OnError         string
+	Preconditions   []Precondition
+	ValidChecksums  []string
+	GoChangeset     bool
+
+	change   []byte
+	rollback []byte
+	// source is the changes before the properties were substituted.
+	source []byte
 }
 
-// ParseHeader will parse the header information.
+// ParseHeader will parse the header information. The header starts with
+// author:id followed by optional attributes like context:dev,test,
+// labels:seed, runOnChange:true, runAlways:true, splitStatements:false, or
+// endDelimiter:$$ separated by spaces. An unknown attribute is an error so a
+// misspelled attribute is not ignored.
 func (cs *Record) ParseHeader(line string) error {
-	arr := strings.Split(line, ":")
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ErrInvalidHeader
+	}
+
+	arr := strings.Split(fields[0], ":")
 	if len(arr) != 2 {
 		return ErrInvalidHeader
 	}
 
 	cs.Author = arr[0]
 	cs.ID = arr[1]
+	cs.SplitStatements = true
+
+	// Parse the attributes.
+	for _, v := range fields[1:] {
+		attr := strings.SplitN(v, ":", 2)
+		if len(attr) != 2 {
+			return ErrInvalidHeader
+		}
+
+		switch attr[0] {
+		case "context", "contextFilter":
+			cs.Contexts = attr[1]
+		case "labels":
+			cs.Labels = attr[1]
+		case "runOnChange", "runAlways", "splitStatements":
+			b, err := strconv.ParseBool(attr[1])
+			if err != nil {
+				return ErrInvalidHeader
+			}
+			switch attr[0] {
+			case "runOnChange":
+				cs.RunOnChange = b
+			case "runAlways":
+				cs.RunAlways = b
+			default:
+				cs.SplitStatements = b
+			}
+		case "endDelimiter":
+			cs.EndDelimiter = attr[1]
+		default:
+			return fmt.Errorf("%w - unknown attribute: %v", ErrInvalidHeader, attr[0])
+		}
+	}
 
 	return nil
 }
@@ -50,10 +126,7 @@ func (cs *Record) SetFileInfo(filename string, version string) {
 
 // AddRollback will add a rollback command.
 func (cs *Record) AddRollback(line string) {
-	if len(cs.rollback) == 0 {
-		cs.rollback = make([]string, 0)
-	}
-	cs.rollback = append(cs.rollback, line)
+	cs.rollback = appendLine(cs.rollback, line)
 }
 
 // AddDescription will add a description.
@@ -64,27 +137,51 @@ func (cs *Record) AddDescription(line string) {
 	}, "\n")
 }
 
+// AddValidChecksum will add a checksum that is accepted for the changeset
+// even though it doesn't match the changes.
+func (cs *Record) AddValidChecksum(line string) {
+	cs.ValidChecksums = append(cs.ValidChecksums, strings.TrimSpace(line))
+}
+
 // AddChange will add a change command.
 func (cs *Record) AddChange(line string) {
-	if len(cs.change) == 0 {
-		cs.change = make([]string, 0)
-	}
-	cs.change = append(cs.change, line)
+	cs.change = appendLine(cs.change, line)
 }
 
 // Changes will return all the changes.
 func (cs *Record) Changes() string {
-	return strings.Join(cs.change, "\n")
+	return string(cs.change)
 }
 
 // Rollbacks will return all the rollbacks.
 func (cs *Record) Rollbacks() string {
-	return strings.Join(cs.rollback, "\n")
+	return string(cs.rollback)
+}
+
+// Statements will return the changes split into statements in the dialect.
+func (cs *Record) Statements(dialect Dialect) []Statement {
+	return cs.split(cs.Changes(), dialect)
+}
+
+// RollbackStatements will return the rollbacks split into statements in the
+// dialect.
+func (cs *Record) RollbackStatements(dialect Dialect) []Statement {
+	return cs.split(cs.Rollbacks(), dialect)
 }
 
-// GenerateChecksum returns an MD5 checksum for the changeset.
-func (cs *Record) GenerateChecksum() string {
-	return md5sum(cs.Changes())
+// split will split the query into statements on the EndDelimiter or return the
+// query as a single statement if SplitStatements is false. SplitStatements is
+// set to true by ParseHeader.
+func (cs *Record) split(query string, dialect Dialect) []Statement {
+	if cs.SplitStatements {
+		return SplitStatements(query, cs.EndDelimiter, dialect)
+	}
+
+	if len(strings.TrimSpace(query)) == 0 {
+		return nil
+	}
+
+	return []Statement{{Query: query, Line: 1}}
 }
 
 // String returns a display of the changeset.
@@ -0,0 +1,143 @@
+package changeset
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+const (
+	// ChecksumMD5 is the version of the MD5 checksum. Checksums without a
+	// version are MD5 checksums from before checksums were versioned.
+	ChecksumMD5 = "1"
+	// ChecksumSHA256 is the version of the SHA-256 checksum.
+	ChecksumSHA256 = "2"
+	// DefaultChecksum is the version of new checksums.
+	DefaultChecksum = ChecksumSHA256
+	// ChecksumNormalized is added to the end of a version, like 2n, when the
+	// changes are normalized before the checksum. Comments are removed and
+	// whitespace outside of quotes is collapsed so reformatting the SQL doesn't
+	// change the checksum.
+	ChecksumNormalized = "n"
+	// ChecksumAny is a valid checksum that matches every checksum.
+	ChecksumAny = "ANY"
+)
+
+var (
+	// ErrUnknownChecksum is when the checksum version is not registered.
+	ErrUnknownChecksum = errors.New("unknown checksum version")
+
+	checksumMutex sync.RWMutex
+	checksums     = map[string]ChecksumFunc{
+		ChecksumMD5:    md5sum,
+		ChecksumSHA256: sha256sum,
+	}
+)
+
+// ChecksumFunc returns the checksum of the changes.
+type ChecksumFunc func(b []byte) string
+
+// RegisterChecksum will make a checksum algorithm available by the version.
+// The version can't contain a colon or end with ChecksumNormalized. If the
+// version is already registered or the func is nil, it panics.
+func RegisterChecksum(version string, fn ChecksumFunc) {
+	checksumMutex.Lock()
+	defer checksumMutex.Unlock()
+
+	if len(version) == 0 || strings.Contains(version, ":") ||
+		strings.HasSuffix(version, ChecksumNormalized) {
+		panic("changeset: invalid checksum version " + version)
+	}
+	if fn == nil {
+		panic("changeset: checksum func is nil")
+	}
+	if _, ok := checksums[version]; ok {
+		panic("changeset: checksum version registered twice " + version)
+	}
+
+	checksums[version] = fn
+}
+
+// ChecksumVersion returns the version of the checksum. A checksum without a
+// version is an MD5 checksum.
+func ChecksumVersion(checksum string) string {
+	i := strings.Index(checksum, ":")
+	if i < 0 {
+		return ChecksumMD5
+	}
+
+	return checksum[:i]
+}
+
+// GenerateChecksum returns a checksum for the changeset with the default
+// version.
+func (cs *Record) GenerateChecksum() string {
+	s, _ := cs.GenerateChecksumVersion(DefaultChecksum)
+	return s
+}
+
+// GenerateChecksumVersion returns a checksum for the changeset prefixed by the
+// version, like 2:<sha256>, or an error if the version is not registered. If
+// the version ends with ChecksumNormalized, the changes are normalized first.
+func (cs *Record) GenerateChecksumVersion(version string) (string, error) {
+	normalized := strings.HasSuffix(version, ChecksumNormalized)
+
+	checksumMutex.RLock()
+	fn, ok := checksums[strings.TrimSuffix(version, ChecksumNormalized)]
+	checksumMutex.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("%w: %v", ErrUnknownChecksum, version)
+	}
+
+	// Use the changes before the properties were substituted.
+	b := cs.change
+	if cs.source != nil {
+		b = cs.source
+	}
+
+	if normalized {
+		return version + ":" + fn([]byte(normalize(string(b), cs.EndDelimiter))), nil
+	}
+
+	return version + ":" + fn(b), nil
+}
+
+// VerifyChecksum returns true if the checksum matches the changeset. The
+// checksum is generated with the same version as the checksum so an older
+// version can still be verified.
+func (cs *Record) VerifyChecksum(checksum string) (bool, error) {
+	// A checksum without a version is an MD5 checksum with no prefix.
+	if !strings.Contains(checksum, ":") {
+		s, err := cs.GenerateChecksumVersion(ChecksumMD5)
+		return ChecksumMD5+":"+checksum == s, err
+	}
+
+	s, err := cs.GenerateChecksumVersion(ChecksumVersion(checksum))
+	if err != nil {
+		return false, err
+	}
+
+	return s == checksum, nil
+}
+
+// IsValidChecksum returns true if the checksum is one of the valid checksums
+// of the changeset. A valid checksum without a version matches any version
+// with the same hash and ANY matches every checksum.
+func (cs *Record) IsValidChecksum(checksum string) bool {
+	hash := checksum[strings.Index(checksum, ":")+1:]
+	for _, v := range cs.ValidChecksums {
+		if strings.EqualFold(v, ChecksumAny) || strings.EqualFold(v, checksum) ||
+			(!strings.Contains(v, ":") && strings.EqualFold(v, hash)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sha256sum will return a checksum from bytes.
+func sha256sum(b []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(b))
+}
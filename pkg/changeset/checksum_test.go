@@ -0,0 +1,154 @@
+package changeset_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/josephspurrier/rove/pkg/changeset"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChecksum(t *testing.T) {
+	cs := new(changeset.Record)
+	cs.AddChange("SELECT 1;")
+	cs.AddChange("SELECT 2;")
+
+	s, err := cs.GenerateChecksumVersion(changeset.ChecksumMD5)
+	assert.Nil(t, err)
+	assert.Equal(t, "1:fc023839d78f2ae186d2044a3e70a4ca", s)
+
+	s, err = cs.GenerateChecksumVersion(changeset.ChecksumSHA256)
+	assert.Nil(t, err)
+	assert.Equal(t, "2:f64316e71b25e7e950d2440c39420b51dfcc2931e172a923d5b7f89fdc67342d", s)
+	assert.Equal(t, s, cs.GenerateChecksum())
+
+	_, err = cs.GenerateChecksumVersion("99")
+	assert.True(t, errors.Is(err, changeset.ErrUnknownChecksum))
+
+	// Ensure each version can be verified.
+	for _, v := range []struct {
+		checksum string
+		expected bool
+	}{
+		{"fc023839d78f2ae186d2044a3e70a4ca", true},
+		{"1:fc023839d78f2ae186d2044a3e70a4ca", true},
+		{"2:f64316e71b25e7e950d2440c39420b51dfcc2931e172a923d5b7f89fdc67342d", true},
+		{"bad", false},
+		{"1:bad", false},
+		{"2:fc023839d78f2ae186d2044a3e70a4ca", false},
+	} {
+		ok, err := cs.VerifyChecksum(v.checksum)
+		assert.Nil(t, err, v.checksum)
+		assert.Equal(t, v.expected, ok, v.checksum)
+	}
+
+	_, err = cs.VerifyChecksum("99:abc")
+	assert.True(t, errors.Is(err, changeset.ErrUnknownChecksum))
+
+	assert.Equal(t, changeset.ChecksumMD5, changeset.ChecksumVersion("fc023839d78f2ae186d2044a3e70a4ca"))
+	assert.Equal(t, changeset.ChecksumSHA256, changeset.ChecksumVersion(s))
+}
+
+func TestRegisterChecksum(t *testing.T) {
+	upper := func(b []byte) string {
+		return strings.ToUpper(string(b))
+	}
+	changeset.RegisterChecksum("test", upper)
+
+	cs := new(changeset.Record)
+	cs.AddChange("select 1;")
+
+	s, err := cs.GenerateChecksumVersion("test")
+	assert.Nil(t, err)
+	assert.Equal(t, "test:SELECT 1;", s)
+
+	ok, err := cs.VerifyChecksum(s)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	assert.Panics(t, func() { changeset.RegisterChecksum("test", upper) })
+	assert.Panics(t, func() { changeset.RegisterChecksum("a:b", upper) })
+	assert.Panics(t, func() { changeset.RegisterChecksum("nil", nil) })
+}
+
+func TestChecksumNormalized(t *testing.T) {
+	checksum := func(lines ...string) string {
+		cs := new(changeset.Record)
+		for _, v := range lines {
+			cs.AddChange(v)
+		}
+		s, err := cs.GenerateChecksumVersion(changeset.ChecksumSHA256 + changeset.ChecksumNormalized)
+		assert.Nil(t, err)
+		assert.Equal(t, "2n", changeset.ChecksumVersion(s))
+		return s
+	}
+
+	expected := checksum("INSERT INTO a (b, c) VALUES ('x  y', \"1\");")
+
+	// Ensure whitespace and comments outside of quotes are ignored.
+	for _, v := range [][]string{
+		{"INSERT INTO a (b, c)", "VALUES ('x  y', \"1\");"},
+		{"INSERT  INTO\ta (b, c)\r", "  VALUES ('x  y', \"1\");  "},
+		{"INSERT INTO a (b, c) -- Add the row.", "VALUES ('x  y', \"1\");"},
+		{"INSERT INTO a /* The table. */ (b, c) VALUES ('x  y', \"1\");"},
+		{"INSERT INTO a(b,c)VALUES('x  y',\"1\");"},
+		{"INSERT INTO a (", "  b,", "  c", ") VALUES (", "  'x  y',", "  \"1\"", ");"},
+	} {
+		assert.Equal(t, expected, checksum(v...), v)
+	}
+
+	// Ensure changes to quotes and the SQL are not ignored.
+	for _, v := range [][]string{
+		{"INSERT INTO a (b, c) VALUES ('x y', \"1\");"},
+		{"INSERT INTO a (b, c) VALUES ('x  y', \"1 \");"},
+		{"INSERT INTO a (b, d) VALUES ('x  y', \"1\");"},
+		{"INSERTINTO a (b, c) VALUES ('x  y', \"1\");"},
+		{"INSERT INTO a (b, c) VALUES ('x  y', \"1\"); -- Add the row.", "SELECT 1;"},
+	} {
+		assert.NotEqual(t, expected, checksum(v...), v)
+	}
+
+	// Ensure a dollar sign in an identifier is not a dollar quote.
+	assert.Equal(t, checksum("CREATE TABLE t (a$b$ int);", "SELECT  1;"),
+		checksum("CREATE TABLE t (a$b$ int); SELECT 1;"))
+
+	// Ensure a normalized checksum can be verified.
+	cs := new(changeset.Record)
+	cs.AddChange("SELECT   1;")
+	ok, err := cs.VerifyChecksum(checksum("SELECT 1; -- Comment."))
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	assert.Panics(t, func() {
+		changeset.RegisterChecksum("3n", func(b []byte) string { return "" })
+	})
+}
+
+func TestValidChecksum(t *testing.T) {
+	cs := new(changeset.Record)
+	assert.False(t, cs.IsValidChecksum("1:abc"))
+
+	cs.AddValidChecksum("2:DEF ")
+	cs.AddValidChecksum("abc")
+	assert.Equal(t, []string{"2:DEF", "abc"}, cs.ValidChecksums)
+
+	for _, v := range []struct {
+		checksum string
+		expected bool
+	}{
+		{"abc", true},
+		{"1:abc", true},
+		{"2n:ABC", true},
+		{"2:def", true},
+		{"1:def", false},
+		{"def", false},
+		{"2:abcd", false},
+	} {
+		assert.Equal(t, v.expected, cs.IsValidChecksum(v.checksum), v.checksum)
+	}
+
+	cs.AddValidChecksum(changeset.ChecksumAny)
+	assert.True(t, cs.IsValidChecksum("1:def"))
+}
@@ -1,16 +1,22 @@
 package changeset
 
 import (
-	"bytes"
 	"crypto/md5"
 	"fmt"
-	"io"
 )
 
-// md5sum will return a checksum from a string.
-func md5sum(s string) string {
-	h := md5.New()
-	r := bytes.NewReader([]byte(s))
-	_, _ = io.Copy(h, r)
-	return fmt.Sprintf("%x", h.Sum(nil))
+// md5sum will return a checksum from bytes.
+func md5sum(b []byte) string {
+	return fmt.Sprintf("%x", md5.Sum(b))
+}
+
+// appendLine will append the line to the buffer separated by a newline. A nil
+// buffer has no lines so the separator is not added.
+func appendLine(b []byte, line string) []byte {
+	if b == nil {
+		b = make([]byte, 0, len(line))
+	} else {
+		b = append(b, '\n')
+	}
+	return append(b, line...)
 }
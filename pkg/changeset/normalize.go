@@ -0,0 +1,85 @@
+package changeset
+
+import (
+	"strings"
+)
+
+// normalize will remove comments and collapse whitespace outside of quotes,
+// backticks, and dollar quotes to a single space. The space is only kept
+// between two words so whitespace around punctuation like parentheses and
+// commas is removed. Line endings are whitespace so CRLF and LF are the same.
+// Dollar quotes are not used if the delimiter starts with a dollar sign or
+// inside of a word. The checksum doesn't depend on the dialect so dollar quotes
+// are used in every dialect.
+func normalize(query, delimiter string) string {
+	var b strings.Builder
+	b.Grow(len(query))
+
+	// space is true if whitespace was skipped since the last text.
+	space := false
+	last := byte(0)
+	write := func(text string) {
+		if space && isWord(last) && isWord(text[0]) {
+			b.WriteByte(' ')
+		}
+		last = text[len(text)-1]
+		space = false
+		b.WriteString(text)
+	}
+
+	i := 0
+	for i < len(query) {
+		rest := query[i:]
+
+		switch {
+		case strings.HasPrefix(rest, "--"):
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			i += end
+			space = true
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += 4
+			}
+			i += end
+			space = true
+		case rest[0] == '\'' || rest[0] == '"' || rest[0] == '`':
+			// The checksum doesn't depend on the dialect so a backslash is
+			// always an escape.
+			end := quoteEnd(rest, true)
+			write(rest[:end])
+			i += end
+		case !strings.HasPrefix(delimiter, "$") && isDollarQuote(query, i):
+			tag := dollarQuote.FindString(rest)
+			end := strings.Index(rest[len(tag):], tag)
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += 2 * len(tag)
+			}
+			write(rest[:end])
+			i += end
+		case rest[0] == ' ' || rest[0] == '\t' || rest[0] == '\n' ||
+			rest[0] == '\r' || rest[0] == '\f' || rest[0] == '\v':
+			i++
+			space = true
+		default:
+			write(rest[:1])
+			i++
+		}
+	}
+
+	return b.String()
+}
+
+// isWord returns true if the character is part of a word like a keyword,
+// identifier, number, or parameter.
+func isWord(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '_' || c == '$' || c == '@' || c >= 0x80
+}
@@ -0,0 +1,154 @@
+package changeset
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// PreconditionSQLCheck is when the result of a query must match the
+	// expected result.
+	PreconditionSQLCheck = "sql-check"
+	// PreconditionTableExists is when a table must exist (or not exist if the
+	// expected result is false).
+	PreconditionTableExists = "table-exists"
+	// PreconditionColumnExists is when a column must exist (or not exist if
+	// the expected result is false).
+	PreconditionColumnExists = "column-exists"
+
+	// OnHalt stops the migration with an error.
+	OnHalt = "HALT"
+	// OnContinue skips the changeset without recording it so it's checked
+	// again on the next migration.
+	OnContinue = "CONTINUE"
+	// OnMarkRan skips the changeset and records it as ran.
+	OnMarkRan = "MARK_RAN"
+	// OnWarn outputs a warning and applies the changeset.
+	OnWarn = "WARN"
+)
+
+var (
+	// ErrInvalidPrecondition is when a precondition is invalid.
+	ErrInvalidPrecondition = errors.New("invalid precondition")
+)
+
+// Precondition is a check that must pass before the changeset is applied.
+type Precondition struct {
+	Type     string
+	Expected string
+	Query    string
+	Table    string
+	Column   string
+}
+
+// String returns a display of the precondition.
+func (p Precondition) String() string {
+	switch p.Type {
+	case PreconditionTableExists:
+		return fmt.Sprintf("%v %v (expected %v)", p.Type, p.Table, p.Expected)
+	case PreconditionColumnExists:
+		return fmt.Sprintf("%v %v.%v (expected %v)", p.Type, p.Table, p.Column,
+			p.Expected)
+	}
+
+	return fmt.Sprintf("%v '%v' (expected %v)", p.Type, p.Query, p.Expected)
+}
+
+// SetPreconditionPolicy will parse the onFail and onError policies that
+// determine what happens when a precondition fails or returns an error. Both
+// policies default to HALT.
+func (cs *Record) SetPreconditionPolicy(line string) error {
+	for _, v := range strings.Fields(line) {
+		attr := strings.SplitN(v, ":", 2)
+		if len(attr) != 2 {
+			return fmt.Errorf("%v - %v", ErrInvalidPrecondition, v)
+		}
+
+		policy := strings.ToUpper(attr[1])
+		switch policy {
+		case OnHalt, OnContinue, OnMarkRan, OnWarn:
+		default:
+			return fmt.Errorf("%v - unknown policy: %v", ErrInvalidPrecondition, attr[1])
+		}
+
+		switch attr[0] {
+		case "onFail":
+			cs.OnFail = policy
+		case "onError":
+			cs.OnError = policy
+		default:
+			return fmt.Errorf("%v - unknown attribute: %v", ErrInvalidPrecondition, attr[0])
+		}
+	}
+
+	return nil
+}
+
+// AddPrecondition will add a precondition of the type. The line starts with
+// attributes like expectedResult:0, tableName:user, or columnName:email. The
+// rest of the line is the query for a sql-check.
+func (cs *Record) AddPrecondition(kind, line string) error {
+	p := Precondition{Type: kind}
+
+	// Set the attributes until a field is not an attribute.
+	rest := strings.TrimSpace(line)
+	for len(rest) > 0 {
+		field := strings.Fields(rest)[0]
+		if !p.setAttribute(field) {
+			break
+		}
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, field))
+	}
+
+	switch kind {
+	case PreconditionSQLCheck:
+		p.Query = rest
+		if len(p.Query) == 0 || len(p.Expected) == 0 {
+			return fmt.Errorf("%v - %v requires expectedResult and a query",
+				ErrInvalidPrecondition, kind)
+		}
+	case PreconditionTableExists, PreconditionColumnExists:
+		if len(rest) > 0 || len(p.Table) == 0 ||
+			(kind == PreconditionColumnExists && len(p.Column) == 0) {
+			return fmt.Errorf("%v - %v requires only tableName, columnName, and expectedResult",
+				ErrInvalidPrecondition, kind)
+		}
+
+		if len(p.Expected) == 0 {
+			p.Expected = "true"
+		}
+		if p.Expected != "true" && p.Expected != "false" {
+			return fmt.Errorf("%v - %v expectedResult must be true or false",
+				ErrInvalidPrecondition, kind)
+		}
+	default:
+		return fmt.Errorf("%v - unknown type: %v", ErrInvalidPrecondition, kind)
+	}
+
+	cs.Preconditions = append(cs.Preconditions, p)
+
+	return nil
+}
+
+// setAttribute will set the attribute from a field like tableName:user and
+// return true or return false if the field is not an attribute.
+func (p *Precondition) setAttribute(field string) bool {
+	attr := strings.SplitN(field, ":", 2)
+	if len(attr) != 2 {
+		return false
+	}
+
+	switch attr[0] {
+	case "expectedResult":
+		p.Expected = attr[1]
+	case "tableName":
+		p.Table = attr[1]
+	case "columnName":
+		p.Column = attr[1]
+	default:
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,61 @@
+package changeset
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+var (
+	// ErrUndefinedProperty is when a property in a changeset is not defined.
+	ErrUndefinedProperty = errors.New("property is not defined")
+
+	// property matches a property like ${schema}.
+	property = regexp.MustCompile(`\$\{([A-Za-z0-9_.\-]+)\}`)
+)
+
+// Substitute will replace the properties like ${schema} in the changes,
+// rollbacks, and preconditions with the values from the lookup. The checksum
+// is still of the changes before the properties are substituted so the same
+// changeset has the same checksum with different values. An error is returned
+// if the lookup doesn't have a property. If there are no properties, the
+// changes are not copied. There is no way to escape a literal ${ so text
+// like ${name} is always a property.
+func (cs *Record) Substitute(lookup func(name string) (string, bool)) error {
+	var missing error
+	replace := func(b []byte) []byte {
+		if !property.Match(b) {
+			return b
+		}
+		return property.ReplaceAllFunc(b, func(m []byte) []byte {
+			name := string(m[2 : len(m)-1])
+			v, ok := lookup(name)
+			if !ok && missing == nil {
+				missing = fmt.Errorf("%w: %v", ErrUndefinedProperty, name)
+			}
+			return []byte(v)
+		})
+	}
+
+	change := replace(cs.change)
+	rollback := replace(cs.rollback)
+	preconditions := make([]Precondition, len(cs.Preconditions))
+	for i, p := range cs.Preconditions {
+		p.Query = string(replace([]byte(p.Query)))
+		p.Table = string(replace([]byte(p.Table)))
+		p.Column = string(replace([]byte(p.Column)))
+		preconditions[i] = p
+	}
+	if missing != nil {
+		return missing
+	}
+
+	if cs.source == nil {
+		cs.source = cs.change
+	}
+	cs.change = change
+	cs.rollback = rollback
+	cs.Preconditions = preconditions
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package changeset_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/josephspurrier/rove/pkg/changeset"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubstitute(t *testing.T) {
+	properties := map[string]string{
+		"schema":   "app",
+		"app_user": "web",
+	}
+	lookup := func(name string) (string, bool) {
+		v, ok := properties[name]
+		return v, ok
+	}
+
+	cs := new(changeset.Record)
+	cs.AddChange("GRANT SELECT ON ${schema}.user TO ${app_user};")
+	cs.AddRollback("REVOKE SELECT ON ${schema}.user FROM ${app_user};")
+	err := cs.AddPrecondition(changeset.PreconditionTableExists, "tableName:${schema}.user")
+	assert.Nil(t, err)
+	checksum := cs.GenerateChecksum()
+
+	err = cs.Substitute(lookup)
+	assert.Nil(t, err)
+	assert.Equal(t, "GRANT SELECT ON app.user TO web;", cs.Changes())
+	assert.Equal(t, "REVOKE SELECT ON app.user FROM web;", cs.Rollbacks())
+	assert.Equal(t, "app.user", cs.Preconditions[0].Table)
+	assert.Equal(t, checksum, cs.GenerateChecksum())
+
+	// Ensure an undefined property is an error and nothing is substituted.
+	cs = new(changeset.Record)
+	cs.AddChange("SELECT * FROM ${schema}.${table};")
+	err = cs.Substitute(lookup)
+	assert.True(t, errors.Is(err, changeset.ErrUndefinedProperty))
+	assert.Equal(t, "property is not defined: table", err.Error())
+	assert.Equal(t, "SELECT * FROM ${schema}.${table};", cs.Changes())
+}
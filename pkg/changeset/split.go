@@ -0,0 +1,234 @@
+package changeset
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+const (
+	// DefaultDelimiter is the delimiter between statements.
+	DefaultDelimiter = ";"
+)
+
+var (
+	// DialectStandard is the SQL syntax of SQLite where # is not a comment, a
+	// backslash is not an escape in quotes, and there are no dollar quotes.
+	DialectStandard = Dialect{}
+	// DialectPostgres is the SQL syntax of PostgreSQL which is the standard
+	// syntax with dollar quotes.
+	DialectPostgres = Dialect{DollarQuotes: true}
+	// DialectMySQL is the SQL syntax of MySQL where # starts a comment and a
+	// backslash escapes a character in quotes.
+	DialectMySQL = Dialect{HashComments: true, BackslashEscapes: true}
+)
+
+var (
+	// dollarQuote matches the start of a PostgreSQL dollar quoted string.
+	dollarQuote = regexp.MustCompile(`^\$[A-Za-z_]*\$`)
+)
+
+// Dialect is the SQL syntax used to split statements.
+type Dialect struct {
+	// HashComments is true if # starts a comment to the end of the line.
+	HashComments bool
+	// BackslashEscapes is true if a backslash escapes a character in quotes.
+	BackslashEscapes bool
+	// DollarQuotes is true if text like $tag$...$tag$ is a quote.
+	DollarQuotes bool
+}
+
+// Statement is a single statement from a changeset.
+type Statement struct {
+	// Query is the statement without the delimiter.
+	Query string
+	// Line is the line in the changeset where the statement starts.
+	Line int
+}
+
+// SplitStatements will split the query into statements on the delimiter. The
+// delimiter is ignored inside of quotes, backticks, dollar quotes, and
+// comments. A delimiter that starts or ends with a letter or digit, like GO,
+// must be a separate word. A line that starts with DELIMITER changes the
+// delimiter for the statements that follow it. If the delimiter is blank,
+// DefaultDelimiter is used. The dialect sets whether # is a comment, whether a
+// backslash is an escape in quotes, and whether there are dollar quotes.
+func SplitStatements(query, delimiter string, dialect Dialect) []Statement {
+	if len(delimiter) == 0 {
+		delimiter = DefaultDelimiter
+	}
+
+	s := &splitter{
+		query:     query,
+		delimiter: delimiter,
+		dialect:   dialect,
+		line:      1,
+		arr:       make([]Statement, 0),
+	}
+	s.split()
+
+	return s.arr
+}
+
+// splitter holds the state while splitting statements.
+type splitter struct {
+	query     string
+	delimiter string
+	dialect   Dialect
+	line      int
+	arr       []Statement
+
+	buf     bytes.Buffer
+	start   int
+	content bool
+}
+
+// split will read each character and add the statements to the array.
+func (s *splitter) split() {
+	i := 0
+	for i < len(s.query) {
+		rest := s.query[i:]
+		lineStart := i == 0 || s.query[i-1] == '\n'
+
+		switch {
+		case lineStart && !s.content && isDelimiterDirective(rest):
+			// Change the delimiter and skip the directive.
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			s.delimiter = strings.TrimSpace(strings.TrimSpace(rest[:end])[len("DELIMITER"):])
+			i += s.skip(rest[:end])
+		case s.isDelimiter(i):
+			s.flush()
+			i += len(s.delimiter)
+		case strings.HasPrefix(rest, "--") || (s.dialect.HashComments && rest[0] == '#'):
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			i += s.write(rest[:end], false)
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += 4
+			}
+			i += s.write(rest[:end], false)
+		case rest[0] == '\'' || rest[0] == '"' || rest[0] == '`':
+			i += s.write(rest[:quoteEnd(rest, s.dialect.BackslashEscapes)], true)
+		case s.dialect.DollarQuotes && !strings.HasPrefix(s.delimiter, "$") &&
+			isDollarQuote(s.query, i):
+			tag := dollarQuote.FindString(rest)
+			end := strings.Index(rest[len(tag):], tag)
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += 2 * len(tag)
+			}
+			i += s.write(rest[:end], true)
+		default:
+			i += s.write(rest[:1], rest[0] != ' ' && rest[0] != '\t' &&
+				rest[0] != '\n' && rest[0] != '\r')
+		}
+	}
+
+	s.flush()
+}
+
+// isDelimiter returns true if the delimiter is at the index. A delimiter that
+// starts or ends with a letter or digit is not matched inside of a word.
+func (s *splitter) isDelimiter(i int) bool {
+	if !strings.HasPrefix(s.query[i:], s.delimiter) {
+		return false
+	}
+
+	end := i + len(s.delimiter)
+	if isIdentifier(s.delimiter[0]) && i > 0 && isIdentifier(s.query[i-1]) {
+		return false
+	} else if isIdentifier(s.delimiter[len(s.delimiter)-1]) && end < len(s.query) &&
+		isIdentifier(s.query[end]) {
+		return false
+	}
+
+	return true
+}
+
+// write will add the text to the current statement and return the length.
+// If content is true, the text is part of the statement instead of only
+// whitespace or comments.
+func (s *splitter) write(text string, content bool) int {
+	if content && !s.content {
+		s.content = true
+		s.start = s.line
+	}
+
+	s.buf.WriteString(text)
+
+	return s.skip(text)
+}
+
+// skip will count the lines in the text and return the length.
+func (s *splitter) skip(text string) int {
+	s.line += strings.Count(text, "\n")
+	return len(text)
+}
+
+// flush will add the current statement to the array if it has content.
+func (s *splitter) flush() {
+	if s.content {
+		s.arr = append(s.arr, Statement{
+			Query: strings.TrimSpace(s.buf.String()),
+			Line:  s.start,
+		})
+	}
+
+	s.buf.Reset()
+	s.content = false
+}
+
+// isDelimiterDirective returns true if the line starts with DELIMITER.
+func isDelimiterDirective(line string) bool {
+	line = strings.TrimLeft(line, " \t")
+	return len(line) > len("DELIMITER ") &&
+		strings.EqualFold(line[:len("DELIMITER ")], "DELIMITER ")
+}
+
+// isDollarQuote returns true if a dollar quote starts at the index. A dollar
+// quote only starts a token so a dollar sign in an identifier like a$b$ is not
+// a dollar quote.
+func isDollarQuote(query string, i int) bool {
+	return query[i] == '$' && (i == 0 || !isWord(query[i-1])) &&
+		dollarQuote.MatchString(query[i:])
+}
+
+// isIdentifier returns true if the character is a letter, digit, or
+// underscore.
+func isIdentifier(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '_'
+}
+
+// quoteEnd returns the index after the closing quote of the quoted text. A
+// quote is escaped by doubling it or with a backslash (except backticks) if
+// backslash is true.
+func quoteEnd(text string, backslash bool) int {
+	q := text[0]
+	for i := 1; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			if backslash && q != '`' {
+				i++
+			}
+		case q:
+			if i+1 < len(text) && text[i+1] == q {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+
+	return len(text)
+}
@@ -0,0 +1,132 @@
+package changeset_test
+
+import (
+	"testing"
+
+	"github.com/josephspurrier/rove/pkg/changeset"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitStatements(t *testing.T) {
+	for _, v := range []struct {
+		query     string
+		delimiter string
+		dialect   changeset.Dialect
+		expected  []changeset.Statement
+	}{
+		{"", "", changeset.DialectStandard, []changeset.Statement{}},
+		{"SELECT 1", "", changeset.DialectStandard, []changeset.Statement{{"SELECT 1", 1}}},
+		{"SELECT 1;\nSELECT 2;\n", "", changeset.DialectStandard, []changeset.Statement{
+			{"SELECT 1", 1},
+			{"SELECT 2", 2},
+		}},
+		{"INSERT INTO a VALUES ('a;b', \"c;d\", `e;f`);\nSELECT 'it''s;', 'it\\'s;';", "", changeset.DialectMySQL,
+			[]changeset.Statement{
+				{"INSERT INTO a VALUES ('a;b', \"c;d\", `e;f`)", 1},
+				{"SELECT 'it''s;', 'it\\'s;'", 2},
+			}},
+		{"SELECT 1; -- comment;\n# comment;\n/* comment;\n*/\nSELECT 2;", "", changeset.DialectMySQL,
+			[]changeset.Statement{
+				{"SELECT 1", 1},
+				{"-- comment;\n# comment;\n/* comment;\n*/\nSELECT 2", 5},
+			}},
+		{"SELECT 1;\n/* only a comment; */", "", changeset.DialectStandard, []changeset.Statement{{"SELECT 1", 1}}},
+		{"CREATE FUNCTION a() RETURNS int AS $body$\nBEGIN\nRETURN 1;\nEND;\n$body$ LANGUAGE plpgsql;\nSELECT 1;", "", changeset.DialectPostgres,
+			[]changeset.Statement{
+				{"CREATE FUNCTION a() RETURNS int AS $body$\nBEGIN\nRETURN 1;\nEND;\n$body$ LANGUAGE plpgsql", 1},
+				{"SELECT 1", 6},
+			}},
+		{"CREATE PROCEDURE a()\nBEGIN\nSELECT 1;\nEND$$\nSELECT 2$$", "$$", changeset.DialectMySQL,
+			[]changeset.Statement{
+				{"CREATE PROCEDURE a()\nBEGIN\nSELECT 1;\nEND", 1},
+				{"SELECT 2", 5},
+			}},
+		{"CREATE TRIGGER a AFTER INSERT ON t\nBEGIN\n    INSERT INTO l (s) VALUES (NEW.s);\nEND$$\nINSERT INTO t (s) VALUES ('a;')$$", "$$", changeset.DialectStandard,
+			[]changeset.Statement{
+				{"CREATE TRIGGER a AFTER INSERT ON t\nBEGIN\n    INSERT INTO l (s) VALUES (NEW.s);\nEND", 1},
+				{"INSERT INTO t (s) VALUES ('a;')", 5},
+			}},
+		{"SELECT 1;\nDELIMITER //\nCREATE PROCEDURE a()\nBEGIN\nSELECT 1;\nEND//\ndelimiter ;\nSELECT 2;", "", changeset.DialectMySQL,
+			[]changeset.Statement{
+				{"SELECT 1", 1},
+				{"CREATE PROCEDURE a()\nBEGIN\nSELECT 1;\nEND", 3},
+				{"SELECT 2", 8},
+			}},
+		{"SELECT data #> '{a,b}', data #>> '{a}', data # 1;\nSELECT 'C:\\';\nSELECT 2;", "", changeset.DialectStandard,
+			[]changeset.Statement{
+				{"SELECT data #> '{a,b}', data #>> '{a}', data # 1", 1},
+				{"SELECT 'C:\\'", 2},
+				{"SELECT 2", 3},
+			}},
+		{"CREATE TABLE t (a$b$ int);\nCREATE TABLE u (x int);", "", changeset.DialectMySQL,
+			[]changeset.Statement{
+				{"CREATE TABLE t (a$b$ int)", 1},
+				{"CREATE TABLE u (x int)", 2},
+			}},
+		{"CREATE TABLE t (a$b$ int);\nCREATE TABLE u (x int);", "", changeset.DialectStandard,
+			[]changeset.Statement{
+				{"CREATE TABLE t (a$b$ int)", 1},
+				{"CREATE TABLE u (x int)", 2},
+			}},
+		{"CREATE TABLE t (a$b$ int);\nSELECT $$a;b$$;", "", changeset.DialectPostgres,
+			[]changeset.Statement{
+				{"CREATE TABLE t (a$b$ int)", 1},
+				{"SELECT $$a;b$$", 2},
+			}},
+		{"SELECT $a$;\nSELECT 1;", "", changeset.DialectMySQL,
+			[]changeset.Statement{
+				{"SELECT $a$", 1},
+				{"SELECT 1", 2},
+			}},
+		{"SELECT GOAL, ALGO FROM t\nGO\nSELECT 2 GO", "GO", changeset.DialectStandard,
+			[]changeset.Statement{
+				{"SELECT GOAL, ALGO FROM t", 1},
+				{"SELECT 2", 3},
+			}},
+		{"CREATE PROCEDURE a()\nBEGIN\nSELECT BACKEND$$;\nEND$$\nSELECT 2END$$", "END$$", changeset.DialectMySQL,
+			[]changeset.Statement{
+				{"CREATE PROCEDURE a()\nBEGIN\nSELECT BACKEND$$;", 1},
+				{"SELECT 2END$$", 5},
+			}},
+	} {
+		assert.Equal(t, v.expected, changeset.SplitStatements(v.query, v.delimiter, v.dialect), v.query)
+	}
+}
+
+func TestStatements(t *testing.T) {
+	for _, v := range []struct {
+		header    string
+		changes   []string
+		rollbacks []string
+		expected  []changeset.Statement
+		rollback  []changeset.Statement
+	}{
+		{"josephspurrier:1", []string{"SELECT 1;", "", "SELECT 2;"}, nil,
+			[]changeset.Statement{{"SELECT 1", 1}, {"SELECT 2", 3}}, []changeset.Statement{}},
+		{"josephspurrier:1 splitStatements:false", []string{"SELECT 1;", "SELECT 2;"}, nil,
+			[]changeset.Statement{{"SELECT 1;\nSELECT 2;", 1}}, nil},
+		{"josephspurrier:1 endDelimiter:GO", []string{"SELECT 1;", "GO"}, []string{"SELECT 2"},
+			[]changeset.Statement{{"SELECT 1;", 1}}, []changeset.Statement{{"SELECT 2", 1}}},
+		{"josephspurrier:1 endDelimiter:$$", []string{"SELECT 1;", "SELECT 2;$$", "SELECT 3$$"},
+			[]string{"SELECT 4;$$"},
+			[]changeset.Statement{{"SELECT 1;\nSELECT 2;", 1}, {"SELECT 3", 3}},
+			[]changeset.Statement{{"SELECT 4;", 1}}},
+	} {
+		cs := new(changeset.Record)
+		err := cs.ParseHeader(v.header)
+		assert.Nil(t, err)
+		for _, line := range v.changes {
+			cs.AddChange(line)
+		}
+		for _, line := range v.rollbacks {
+			cs.AddRollback(line)
+		}
+		assert.Equal(t, v.expected, cs.Statements(changeset.DialectStandard), v.header)
+		assert.Equal(t, v.rollback, cs.RollbackStatements(changeset.DialectStandard), v.header)
+	}
+
+	cs := new(changeset.Record)
+	err := cs.ParseHeader("josephspurrier:1 splitStatements:no")
+	assert.Equal(t, changeset.ErrInvalidHeader, err)
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"reflect"
 	"strconv"
+	"strings"
 )
 
 // Unmarshal will fill a struct from environment variables. It supports struct
@@ -66,3 +67,18 @@ func Unmarshal(dst interface{}, prefix string) (err error) {
 
 	return
 }
+
+// Lookup returns the environment variable for the name with the prefix and
+// true if it's set. The name is converted to upper case and any character
+// other than a letter, number, or underscore is replaced with an underscore so
+// app.user with the prefix ROVE_ is ROVE_APP_USER.
+func Lookup(name string, prefix string) (string, bool) {
+	key := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, strings.ToUpper(name))
+
+	return os.LookupEnv(prefix + key)
+}
@@ -101,3 +101,19 @@ func TestUnmarshalError(t *testing.T) {
 	assert.Equal(t, false, g.SSL)
 	os.Unsetenv("DB_PORT")
 }
+
+func TestLookup(t *testing.T) {
+	os.Setenv("ROVE_APP_USER", "a")
+	defer os.Unsetenv("ROVE_APP_USER")
+
+	v, ok := env.Lookup("app.user", "ROVE_")
+	assert.True(t, ok)
+	assert.Equal(t, "a", v)
+
+	v, ok = env.Lookup("app-user", "ROVE_")
+	assert.True(t, ok)
+	assert.Equal(t, "a", v)
+
+	_, ok = env.Lookup("app.user", "")
+	assert.False(t, ok)
+}
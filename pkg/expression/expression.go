@@ -0,0 +1,217 @@
+// Package expression evaluates boolean expressions of names like contexts
+// and labels.
+package expression
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrInvalidExpression is when the expression cannot be parsed.
+	ErrInvalidExpression = errors.New("invalid expression")
+)
+
+// Expression is a parsed boolean expression of names. Names are combined
+// with "and", "or" (or a comma), "not" (or "!"), and parentheses. The names
+// are case insensitive.
+type Expression struct {
+	root node
+}
+
+// node is an element of the expression tree.
+type node interface {
+	match(names map[string]bool) bool
+}
+
+type nameNode string
+
+func (n nameNode) match(names map[string]bool) bool {
+	return names[string(n)]
+}
+
+type notNode struct {
+	n node
+}
+
+func (n notNode) match(names map[string]bool) bool {
+	return !n.n.match(names)
+}
+
+type andNode struct {
+	left, right node
+}
+
+func (n andNode) match(names map[string]bool) bool {
+	return n.left.match(names) && n.right.match(names)
+}
+
+type orNode struct {
+	left, right node
+}
+
+func (n orNode) match(names map[string]bool) bool {
+	return n.left.match(names) || n.right.match(names)
+}
+
+// Parse will parse the expression or return an error. An empty expression
+// matches everything.
+func Parse(s string) (*Expression, error) {
+	p := &parser{tokens: tokenize(s)}
+	if len(p.tokens) == 0 {
+		return &Expression{}, nil
+	}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("%v - unexpected '%v' in: %v", ErrInvalidExpression,
+			p.tokens[p.pos], s)
+	}
+
+	return &Expression{root: root}, nil
+}
+
+// Match returns true if the names satisfy the expression. If the expression
+// is empty, it returns true.
+func (e *Expression) Match(names []string) bool {
+	if e == nil || e.root == nil {
+		return true
+	}
+
+	m := make(map[string]bool)
+	for _, v := range names {
+		m[strings.ToLower(v)] = true
+	}
+
+	return e.root.match(m)
+}
+
+// Split returns the names from a comma separated list.
+func Split(s string) []string {
+	arr := make([]string, 0)
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if len(v) > 0 {
+			arr = append(arr, v)
+		}
+	}
+
+	return arr
+}
+
+// tokenize will split the expression into names, operators, and parentheses.
+func tokenize(s string) []string {
+	tokens := make([]string, 0)
+	word := ""
+
+	flush := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, strings.ToLower(word))
+			word = ""
+		}
+	}
+
+	for _, c := range s {
+		switch c {
+		case ' ', '\t', '\n', '\r':
+			flush()
+		case '(', ')', '!', ',':
+			flush()
+			tokens = append(tokens, string(c))
+		default:
+			word += string(c)
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+// parser is a recursive descent parser of the tokens.
+type parser struct {
+	tokens []string
+	pos    int
+}
+
+// peek returns the current token or a blank string at the end.
+func (p *parser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+// parseOr parses: and (("or" | ",") and)*
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek() == "or" || p.peek() == "," {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+
+	return left, nil
+}
+
+// parseAnd parses: not ("and" not)*
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek() == "and" {
+		p.pos++
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+
+	return left, nil
+}
+
+// parseNot parses: ("not" | "!") not | "(" or ")" | name
+func (p *parser) parseNot() (node, error) {
+	t := p.peek()
+	switch t {
+	case "":
+		return nil, fmt.Errorf("%v - unexpected end", ErrInvalidExpression)
+	case "not", "!":
+		p.pos++
+		n, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{n}, nil
+	case "(":
+		p.pos++
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("%v - missing ')'", ErrInvalidExpression)
+		}
+		p.pos++
+		return n, nil
+	case ")", "and", "or", ",":
+		return nil, fmt.Errorf("%v - unexpected '%v'", ErrInvalidExpression, t)
+	}
+
+	p.pos++
+	return nameNode(t), nil
+}
@@ -0,0 +1,58 @@
+package expression_test
+
+import (
+	"testing"
+
+	"github.com/josephspurrier/rove/pkg/expression"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	for _, v := range []struct {
+		expr  string
+		names []string
+		match bool
+	}{
+		{"", []string{"dev"}, true},
+		{"", nil, true},
+		{"dev", []string{"dev"}, true},
+		{"DEV", []string{"test", "dev"}, true},
+		{"dev", []string{"prod"}, false},
+		{"dev", nil, false},
+		{"dev, test", []string{"test"}, true},
+		{"dev or test", []string{"prod"}, false},
+		{"dev and test", []string{"dev"}, false},
+		{"dev and test", []string{"test", "dev"}, true},
+		{"!prod", []string{"dev"}, true},
+		{"not prod", []string{"prod"}, false},
+		{"!prod", nil, true},
+		{"(dev or test) and !seed", []string{"test"}, true},
+		{"(dev or test) and !seed", []string{"test", "seed"}, false},
+		{"dev or test and seed", []string{"dev"}, true},
+		{"not (dev or test)", []string{"prod"}, true},
+	} {
+		e, err := expression.Parse(v.expr)
+		assert.Nil(t, err, v.expr)
+		assert.Equal(t, v.match, e.Match(v.names), v.expr)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	for _, v := range []string{
+		"dev and",
+		"or dev",
+		"(dev",
+		"dev)",
+		"dev test",
+		"!",
+	} {
+		_, err := expression.Parse(v)
+		assert.NotNil(t, err, v)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	assert.Equal(t, []string{"dev", "test"}, expression.Split(" dev, ,test "))
+	assert.Equal(t, []string{}, expression.Split(""))
+}
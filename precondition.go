@@ -0,0 +1,113 @@
+package rove
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/josephspurrier/rove/pkg/changeset"
+)
+
+// checkPreconditions will check the preconditions of the changeset and return
+// a blank string if the changeset should be applied or the policy to use
+// instead. An error is returned if the policy is HALT.
+func (r *Rove) checkPreconditions(cs changeset.Record) (string, error) {
+	q, ok := r.db.(Querier)
+	if !ok {
+		return "", errors.New("error - changelog does not support preconditions")
+	}
+
+	for _, p := range cs.Preconditions {
+		passed, err := checkPrecondition(q, p)
+
+		var policy, reason string
+		if err != nil {
+			policy = cs.OnError
+			reason = fmt.Sprintf("error on precondition %v - %v", p.String(), err.Error())
+		} else if !passed {
+			policy = cs.OnFail
+			reason = fmt.Sprintf("precondition failed %v", p.String())
+		} else {
+			continue
+		}
+
+		if len(policy) == 0 || policy == changeset.OnHalt {
+			return "", fmt.Errorf("error on changeset %v:%v - %v", cs.Author, cs.ID, reason)
+		}
+
+		if r.Verbose {
+			fmt.Printf("Precondition %v: %v:%v (%v) %v\n", policy, cs.Author, cs.ID,
+				cs.Filename, reason)
+		}
+
+		// Check the rest of the preconditions after a warning.
+		if policy != changeset.OnWarn {
+			return policy, nil
+		}
+	}
+
+	return "", nil
+}
+
+// checkPrecondition returns true if the precondition passed.
+func checkPrecondition(q Querier, p changeset.Precondition) (bool, error) {
+	switch p.Type {
+	case changeset.PreconditionTableExists:
+		exists, err := q.TableExists(p.Table)
+		return strconv.FormatBool(exists) == p.Expected, err
+	case changeset.PreconditionColumnExists:
+		exists, err := q.ColumnExists(p.Table, p.Column)
+		return strconv.FormatBool(exists) == p.Expected, err
+	}
+
+	v, err := q.QueryValue(p.Query)
+	return strings.TrimSpace(v) == p.Expected, err
+}
+
+// markRan will record the changeset in the changelog without applying it. The
+// offset is the number of records already written on a dry run and the reason
+// is written in the dry run comment.
+func (r *Rove) markRan(cs changeset.Record, offset int, reason string) error {
+	// Count the number of rows.
+	count, err := r.count()
+	if err != nil {
+		return fmt.Errorf("error on counting changelog rows: %v", err)
+	}
+
+	record := cs
+	record.OrderExecuted = count + offset + 1
+	record.DateExecuted = time.Now()
+	record.Checksum, err = r.checksum(cs)
+	if err != nil {
+		return err
+	}
+	record.ExecType = changeset.ExecTypeMarkRan
+
+	// Write the record instead of inserting it on a dry run.
+	if r.DryRun != nil {
+		buf := new(bytes.Buffer)
+		fmt.Fprintf(buf, "-- Changeset %v:%v (%v) checksum %v\n", cs.Author, cs.ID,
+			cs.Filename, record.Checksum)
+		fmt.Fprintf(buf, "-- Changeset is marked as ran because %v.\n", reason)
+		if c, ok := r.db.(ChangelogSQL); ok {
+			writeSQL(buf, c.InsertSQL(record))
+		}
+		fmt.Fprintln(buf)
+
+		return r.writeDryRun(buf)
+	}
+
+	err = r.db.Insert(record)
+	if err != nil {
+		return fmt.Errorf("error on inserting changelog record: %v", err)
+	}
+
+	if r.Verbose {
+		fmt.Printf("Marked as ran: %v\n", record.String())
+	}
+
+	return nil
+}
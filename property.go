@@ -0,0 +1,28 @@
+package rove
+
+import (
+	"fmt"
+
+	"github.com/josephspurrier/rove/pkg/changeset"
+	"github.com/josephspurrier/rove/pkg/env"
+)
+
+// substitute will replace the properties in the changeset. The value of a
+// property is from Properties, then the properties defined in the migration
+// file, and then the environment variables.
+func (r *Rove) substitute(cs *changeset.Record, properties map[string]string) error {
+	err := cs.Substitute(func(name string) (string, bool) {
+		if v, ok := r.Properties[name]; ok {
+			return v, true
+		}
+		if v, ok := properties[name]; ok {
+			return v, true
+		}
+		return env.Lookup(name, r.EnvPrefix)
+	})
+	if err != nil {
+		return fmt.Errorf("error on changeset %v:%v - %w", cs.Author, cs.ID, err)
+	}
+
+	return nil
+}
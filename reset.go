@@ -3,16 +3,46 @@ package rove
 import (
 	"errors"
 	"fmt"
+
+	"github.com/josephspurrier/rove/pkg/changeset"
 )
 
-// Reset will remove all migrations. If max is 0, all rollbacks are run.
-func (r *Rove) Reset(max int) error {
+// Reset will remove all migrations. If max is 0, all rollbacks are run. If
+// DryRun is set, the SQL is written to it instead.
+func (r *Rove) Reset(max int) (err error) {
+	// Prevent other processes from changing the changelog.
+	release, err := r.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock(release, &err)
+
+	return r.reset(max)
+}
+
+// reset will remove all migrations without acquiring the changelog lock. If
+// max is 0, all rollbacks are run.
+func (r *Rove) reset(max int) error {
+	// A dry run on a missing changelog has nothing to rollback.
+	missing, err := r.dryRunMissing()
+	if err != nil {
+		return err
+	} else if missing {
+		return r.rollbackRecords(nil, max)
+	}
+
 	// Get an array of changesets from the database.
 	results, err := r.db.Changesets(true)
 	if err != nil {
 		return err
 	}
 
+	return r.rollbackRecords(results, max)
+}
+
+// rollbackRecords will rollback the changesets of the records in the order of
+// the records. If max is 0, all rollbacks are run.
+func (r *Rove) rollbackRecords(results []changeset.Record, max int) error {
 	// Get the changesets.
 	m, err := r.loadChangesets()
 	if err != nil {
@@ -26,6 +56,17 @@ func (r *Rove) Reset(max int) error {
 		return nil
 	}
 
+	// Ensure the interrupted changesets are repaired first because their
+	// changes may not have been applied.
+	for i, rs := range results {
+		if max != 0 && i >= max {
+			break
+		}
+		if rs.ExecType == changeset.ExecTypePending {
+			return interruptedError(rs)
+		}
+	}
+
 	if r.Verbose {
 		fmt.Printf("Changesets rollback (request: %v):\n", max)
 	}
@@ -41,41 +82,63 @@ func (r *Rove) Reset(max int) error {
 			return errors.New("changeset is missing: " + id)
 		}
 
-		tx, err := r.db.BeginTx()
-		if err != nil {
-			return fmt.Errorf("error on begin transaction - %v", err.Error())
-		}
+		// Write the rollback instead of running it on a dry run.
+		if r.DryRun != nil {
+			err = r.writeRollback(cs, rs)
+			if err != nil {
+				return err
+			}
+		} else {
+			err = r.rollback(cs, rs)
+			if err != nil {
+				return err
+			}
 
-		// Execute the query.
-		err = tx.Exec(cs.Rollbacks())
-		if err != nil {
-			return fmt.Errorf("error on rollback %v:%v - %v", cs.Author, cs.ID, err.Error())
+			if r.Verbose {
+				fmt.Printf("Applied: %v\n", rs.String())
+			}
 		}
 
-		err = tx.Commit()
-		if err != nil {
-			errr := tx.Rollback()
-			if errr != nil {
-				return fmt.Errorf("error on commit rollback %v:%v - %v", cs.Author, cs.ID, errr.Error())
-			}
-			return fmt.Errorf("error on commit %v:%v - %v", cs.Author, cs.ID, err.Error())
+		// Only perform the maxium number of changes based on the max value.
+		maxCounter++
+		if max != 0 && maxCounter >= max {
+			break
 		}
+	}
+
+	return nil
+}
 
-		// Delete the record.
-		err = r.db.Delete(cs.ID, cs.Author, cs.Filename)
+// rollback will run the rollback of the changeset and remove the record from
+// the changelog. If the changelog doesn't support transactional DDL, the record
+// is marked as pending before the rollback runs and then deleted in the same
+// transaction as the rollback. If the changeset was marked as ran, only the
+// record is removed.
+func (r *Rove) rollback(cs changeset.Record, record changeset.Record) error {
+	// Remove the record of a changeset that was never applied.
+	if record.ExecType == changeset.ExecTypeMarkRan {
+		err := r.db.Delete(cs.ID, cs.Author, cs.Filename)
 		if err != nil {
-			return err
+			return fmt.Errorf("error on rollback %v:%v - %v", cs.Author, cs.ID, err.Error())
 		}
+		return nil
+	}
 
-		if r.Verbose {
-			fmt.Printf("Applied: %v\n", rs.String())
+	if !transactionalDDL(r.db) && record.ExecType != changeset.ExecTypeRollbackPending {
+		p := record
+		p.ExecType = changeset.ExecTypeRollbackPending
+		err := r.db.Update(p)
+		if err != nil {
+			return fmt.Errorf("error on updating changelog record: %v", err)
 		}
+	}
 
-		// Only perform the maxium number of changes based on the max value.
-		maxCounter++
-		if max != 0 && maxCounter >= max {
-			break
-		}
+	// Execute the query and delete the record.
+	err := r.execTx(r.down(cs), func(tx Transaction) error {
+		return tx.Delete(cs.ID, cs.Author, cs.Filename)
+	})
+	if err != nil {
+		return fmt.Errorf("error on rollback %v:%v - %v", cs.Author, cs.ID, err.Error())
 	}
 
 	return nil
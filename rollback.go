@@ -1,31 +1,127 @@
 package rove
 
 import (
+	"errors"
 	"fmt"
+	"time"
+
+	"github.com/josephspurrier/rove/pkg/changeset"
 )
 
-// Rollback will rollback a number of changesets to a tag.
-func (r *Rove) Rollback(tag string) error {
+// Rollback will rollback the changesets applied after the changeset with the
+// tag in the reverse order they were applied. If DryRun is set, the SQL is
+// written to it instead.
+func (r *Rove) Rollback(tag string) (err error) {
 	if len(tag) == 0 {
 		return fmt.Errorf("error - rollback tag cannot be empty")
 	}
 
-	// Get the number of max queries to run.
-	max, err := r.db.Rollback(tag)
+	return r.rollbackPlan(fmt.Sprintf("Found tag (%v)", tag), func() ([]changeset.Record, error) {
+		return r.db.Rollback(tag)
+	})
+}
+
+// RollbackToDate will rollback the changesets applied after the date in the
+// reverse order they were applied. If DryRun is set, the SQL is written to it
+// instead.
+func (r *Rove) RollbackToDate(date time.Time) (err error) {
+	if date.IsZero() {
+		return fmt.Errorf("error - rollback date cannot be empty")
+	}
+
+	return r.rollbackPlan(fmt.Sprintf("Found date (%v)", date.Format(time.RFC3339)), func() ([]changeset.Record, error) {
+		results, err := r.db.Changesets(true)
+		if err != nil {
+			return nil, err
+		}
+
+		out := make([]changeset.Record, 0)
+		for _, rs := range results {
+			if rs.DateExecuted.After(date) {
+				out = append(out, rs)
+			}
+		}
+
+		return out, nil
+	})
+}
+
+// RollbackToChangeset will rollback the changesets applied after the changeset
+// in the reverse order they were applied. The changeset itself is not rolled
+// back. The filename is the filename of the changeset in the changelog. If
+// DryRun is set, the SQL is written to it instead.
+func (r *Rove) RollbackToChangeset(author, id, filename string) (err error) {
+	if len(author) == 0 || len(id) == 0 || len(filename) == 0 {
+		return fmt.Errorf("error - rollback changeset cannot be empty")
+	}
+
+	name := fmt.Sprintf("%v:%v (%v)", author, id, filename)
+	return r.rollbackPlan(fmt.Sprintf("Found changeset %v", name), func() ([]changeset.Record, error) {
+		record, err := r.db.ChangesetApplied(id, author, filename)
+		if err != nil {
+			return nil, err
+		} else if record == nil {
+			return nil, errors.New("changeset not found in database: " + name)
+		}
+
+		results, err := r.db.Changesets(true)
+		if err != nil {
+			return nil, err
+		}
+
+		out := make([]changeset.Record, 0)
+		for _, rs := range results {
+			if rs.OrderExecuted > record.OrderExecuted {
+				out = append(out, rs)
+			}
+		}
+
+		return out, nil
+	})
+}
+
+// rollbackPlan will get the changesets to rollback from the plan and then
+// rollback them in order while holding the changelog lock. The changesets are
+// shown before they are rolled back.
+func (r *Rove) rollbackPlan(found string, plan func() ([]changeset.Record, error)) (err error) {
+	// Prevent other processes from changing the changelog.
+	release, err := r.lock()
 	if err != nil {
 		return err
 	}
+	defer unlock(release, &err)
 
+	// A dry run on a missing changelog has nothing to rollback.
+	missing, err := r.dryRunMissing()
+	if err != nil {
+		return err
+	} else if missing {
+		return r.rollbackRecords(nil, 0)
+	}
+
+	// Get the changesets to rollback.
+	results, err := plan()
+	if err != nil {
+		return err
+	}
+
+	// Show the plan before the changesets are rolled back.
 	if r.Verbose {
-		fmt.Printf("Found tag (%v), will rollback (%v) changeset(s)\n", tag, max)
+		fmt.Printf("%v, will rollback (%v) changeset(s):\n", found, len(results))
+		for _, rs := range results {
+			fmt.Printf("%v\n", rs.String())
+		}
 	}
 
 	// Rollback the changesets.
-	err = r.Reset(max)
+	err = r.rollbackRecords(results, 0)
+	if err != nil {
+		return err
+	}
 
 	if r.Verbose {
 		fmt.Printf("Rollback complete\n")
 	}
 
-	return err
+	return nil
 }
@@ -1,5 +1,13 @@
 package rove
 
+import (
+	"context"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"time"
+)
+
 const (
 	appVersion = "1.0"
 )
@@ -10,13 +18,59 @@ type Rove struct {
 	Verbose bool
 	// Checksum determines how operations continue if checksums don't match.
 	Checksum ChecksumMode
+	// ChecksumVersion is the version of the checksum algorithm recorded for
+	// changesets, like changeset.ChecksumSHA256. Changesets with a checksum of
+	// another version are verified with that version and then upgraded. If it's
+	// blank, changeset.DefaultChecksum is used.
+	ChecksumVersion string
+	// ChecksumNormalize is whether comments and whitespace are ignored in the
+	// checksum so reformatting the SQL doesn't change it. The checksum version
+	// is recorded with changeset.ChecksumNormalized, like 2n.
+	ChecksumNormalize bool
+	// LockWait is how long to wait for the changelog lock before returning an
+	// error. If it's 0, DefaultLockWait is used.
+	LockWait time.Duration
+	// LockStale is how long a changelog lock can be held before it's considered
+	// stale and taken over. If it's 0, the lock is never considered stale. The
+	// lock is not refreshed while it's held so it must be longer than the
+	// longest migration.
+	LockStale time.Duration
+	// DryRun is where the SQL is written instead of being run on the database.
+	// If it's nil, the SQL is run on the database.
+	DryRun io.Writer
+	// Contexts is an expression like "dev and !test" that determines which
+	// changesets with a context attribute are applied. If it's blank, all
+	// changesets are applied.
+	Contexts string
+	// Labels is an expression like "seed or fixture" that determines which
+	// changesets with a labels attribute are applied. If it's blank, all
+	// changesets are applied.
+	Labels string
+	// Properties are the values of the properties like ${schema} in the
+	// changesets. They take precedence over the properties defined in the
+	// migration file and the environment variables.
+	Properties map[string]string
+	// EnvPrefix is the prefix of the environment variables for the properties
+	// that are not defined elsewhere, like ROVE_ for ${schema} as ROVE_SCHEMA.
+	EnvPrefix string
+	// Context is passed to the functions of the Go changesets. If it's nil,
+	// context.Background() is used.
+	Context context.Context
 
 	// file is the full path to the migration file.
 	file string
+	// fsys is the file system to load the migration file and includes from.
+	fsys fs.FS
 	// changeset is text with changesets.
 	changeset string
 	// db is a migration.
 	db Changelog
+	// goChangesets are the registered Go changesets in the order they were
+	// registered.
+	goChangesets []goChangeset
+	// missingChangelog is true on a dry run when the changelog doesn't exist
+	// so it's treated as empty instead of being created.
+	missingChangelog bool
 }
 
 // ChecksumMode represents how to handle checksums on migrations.
@@ -38,14 +92,28 @@ const (
 func NewFileMigration(db Changelog, filename string) *Rove {
 	return &Rove{
 		db:   db,
-		file: filename,
+		file: filepath.ToSlash(filename),
+		fsys: osFS{},
 	}
 }
 
-// NewChangesetMigration returns a changeset migration object.
+// NewChangesetMigration returns a changeset migration object. Includes are
+// loaded relative to the working directory.
 func NewChangesetMigration(db Changelog, changeset string) *Rove {
 	return &Rove{
 		db:        db,
 		changeset: changeset,
+		fsys:      osFS{},
+	}
+}
+
+// NewFSMigration returns a migration object that loads the migration file at
+// root and its includes from the file system, like an embed.FS. The root and
+// includes are slash separated paths in the file system.
+func NewFSMigration(db Changelog, fsys fs.FS, root string) *Rove {
+	return &Rove{
+		db:   db,
+		file: root,
+		fsys: fsys,
 	}
 }
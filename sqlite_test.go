@@ -0,0 +1,1285 @@
+package rove_test
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/josephspurrier/rove"
+	"github.com/josephspurrier/rove/pkg/adapter/sqlite"
+	"github.com/josephspurrier/rove/pkg/changeset"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newSQLite returns an in-memory SQLite changelog.
+func newSQLite(t *testing.T) *sqlite.SQLite {
+	s, err := sqlite.New(&sqlite.Connection{Name: sqlite.Memory})
+	assert.Nil(t, err)
+	return s
+}
+
+func TestSQLiteMigration(t *testing.T) {
+	for _, f := range []func() *rove.Rove{
+		func() *rove.Rove {
+			// Set up rove.
+			r := rove.NewFileMigration(newSQLite(t), "testdata/sqlite/success.sql")
+			r.Verbose = true
+			return r
+		},
+		func() *rove.Rove {
+			// Read the file into a string.
+			b, err := ioutil.ReadFile("testdata/sqlite/success.sql")
+			assert.Nil(t, err)
+
+			// Set up rove.
+			r := rove.NewChangesetMigration(newSQLite(t), string(b))
+			r.Verbose = true
+			return r
+		},
+	} {
+		r := f()
+
+		// Run migration.
+		err := r.Migrate(0)
+		assert.Nil(t, err)
+
+		// Get the status.
+		s, err := r.Status()
+		assert.Nil(t, err)
+		assert.Equal(t, "3", s.Last.ID)
+		assert.Equal(t, "josephspurrier", s.Last.Author)
+
+		// Run migration again.
+		err = r.Migrate(0)
+		assert.Nil(t, err)
+
+		// Remove all migrations.
+		err = r.Reset(0)
+		assert.Nil(t, err)
+
+		// Get the status.
+		s, err = r.Status()
+		assert.Nil(t, err)
+		assert.Nil(t, s.Last)
+
+		// Run 2 migrations.
+		err = r.Migrate(2)
+		assert.Nil(t, err)
+
+		// Get the status.
+		s, err = r.Status()
+		assert.Nil(t, err)
+		assert.Equal(t, "2", s.Last.ID)
+
+		// Remove 1 migration.
+		err = r.Reset(1)
+		assert.Nil(t, err)
+
+		// Get the status.
+		s, err = r.Status()
+		assert.Nil(t, err)
+		assert.Equal(t, "1", s.Last.ID)
+	}
+}
+
+func TestSQLiteTag(t *testing.T) {
+	// Set up rove.
+	r := rove.NewFileMigration(newSQLite(t), "testdata/sqlite/success.sql")
+	r.Verbose = true
+
+	// Run migration.
+	err := r.Migrate(1)
+	assert.Nil(t, err)
+
+	// Tag the migration.
+	err = r.Tag("jas1")
+	assert.Nil(t, err)
+
+	// Run the rest of the migrations.
+	err = r.Migrate(0)
+	assert.Nil(t, err)
+
+	// Rollback to the tag.
+	err = r.Rollback("jas1")
+	assert.Nil(t, err)
+
+	// Get the status.
+	s, err := r.Status()
+	assert.Nil(t, err)
+	assert.Equal(t, "1", s.Last.ID)
+	assert.Equal(t, "jas1", s.Last.Tag)
+
+	// Attempt rollback again.
+	err = r.Rollback("jas1")
+	assert.NotNil(t, err)
+
+	// Attempt to tag with the same tag.
+	err = r.Migrate(1)
+	assert.Nil(t, err)
+	err = r.Tag("jas1")
+	assert.NotNil(t, err)
+}
+
+// nonTransactional is a SQLite changelog that acts like a database without
+// transactional DDL.
+type nonTransactional struct {
+	*sqlite.SQLite
+}
+
+// TransactionalDDL returns false.
+func (n nonTransactional) TransactionalDDL() bool {
+	return false
+}
+
+func TestSQLiteRollbackOrder(t *testing.T) {
+	s := newSQLite(t)
+
+	// Set up rove with IDs that don't sort in the order they are applied.
+	r := rove.NewChangesetMigration(s, `--changeset josephspurrier:9
+CREATE TABLE a (id INTEGER);
+--rollback DROP TABLE a;
+
+--changeset josephspurrier:10
+CREATE TABLE b (id INTEGER);
+--rollback DROP TABLE b;
+
+--changeset josephspurrier:f47ac10b-58cc-4372-a567-0e02b2c3d479
+INSERT INTO b (id) VALUES (1);
+--rollback DELETE FROM b WHERE id = 1;
+
+--changeset josephspurrier:2
+INSERT INTO b (id) VALUES (2);
+--rollback DELETE FROM b WHERE id = 2;`)
+
+	// Tag the first changeset and then apply the rest.
+	err := r.Migrate(1)
+	assert.Nil(t, err)
+	err = r.Tag("v1")
+	assert.Nil(t, err)
+	err = r.Migrate(0)
+	assert.Nil(t, err)
+
+	// Ensure the changelog returns the changesets after the tag in the reverse
+	// order they were applied.
+	records, err := s.Rollback("v1")
+	assert.Nil(t, err)
+	ids := make([]string, 0)
+	for _, v := range records {
+		ids = append(ids, v.ID)
+	}
+	assert.Equal(t, []string{"2", "f47ac10b-58cc-4372-a567-0e02b2c3d479", "10"}, ids)
+
+	// Ensure exactly those changesets are rolled back.
+	err = r.Rollback("v1")
+	assert.Nil(t, err)
+
+	st, err := r.Status()
+	assert.Nil(t, err)
+	if assert.NotNil(t, st) {
+		assert.Equal(t, "9", st.Last.ID)
+		assert.Equal(t, 3, len(st.Pending))
+	}
+}
+
+func TestSQLiteRollbackTo(t *testing.T) {
+	s := newSQLite(t)
+	r := rove.NewFileMigration(s, "testdata/sqlite/success.sql")
+	r.Verbose = true
+
+	// Set the date of each changeset an hour apart.
+	migrate := func() {
+		err := r.Migrate(0)
+		assert.Nil(t, err)
+		records, err := s.Changesets(false)
+		assert.Nil(t, err)
+		for i, v := range records {
+			v.DateExecuted = time.Date(2020, 1, 1, i, 0, 0, 0, time.UTC)
+			assert.Nil(t, s.Update(v))
+		}
+	}
+	last := func() string {
+		st, err := r.Status()
+		assert.Nil(t, err)
+		if st == nil || st.Last == nil {
+			return ""
+		}
+		return st.Last.ID
+	}
+
+	// Rollback the changesets applied after the date.
+	migrate()
+	err := r.RollbackToDate(time.Date(2020, 1, 1, 0, 30, 0, 0, time.UTC))
+	assert.Nil(t, err)
+	assert.Equal(t, "1", last())
+
+	migrate()
+	err = r.RollbackToDate(time.Date(2020, 1, 1, 2, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+	assert.Equal(t, "3", last())
+
+	// Rollback the changesets applied after the changeset.
+	err = r.RollbackToChangeset("josephspurrier", "2", "success.sql")
+	assert.Nil(t, err)
+	assert.Equal(t, "2", last())
+
+	err = r.RollbackToChangeset("josephspurrier", "3", "success.sql")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "changeset not found in database")
+
+	assert.NotNil(t, r.RollbackToDate(time.Time{}))
+	assert.NotNil(t, r.RollbackToChangeset("josephspurrier", "", "success.sql"))
+}
+
+func TestSQLiteMigrateTo(t *testing.T) {
+	s := newSQLite(t)
+	r := rove.NewChangesetMigration(s, `--changeset josephspurrier:1
+CREATE TABLE a (id INTEGER);
+--rollback DROP TABLE a;
+
+--changeset josephspurrier:release-1
+--tagDatabase v1
+
+--changeset josephspurrier:2
+CREATE TABLE b (id INTEGER);
+--rollback DROP TABLE b;
+
+--changeset josephspurrier:release-2
+--tagDatabase v2
+
+--changeset josephspurrier:3
+CREATE TABLE c (id INTEGER);
+--rollback DROP TABLE c;`)
+	r.Verbose = true
+
+	ids := func() []string {
+		records, err := s.Changesets(false)
+		assert.Nil(t, err)
+		arr := make([]string, 0)
+		for _, v := range records {
+			arr = append(arr, v.ID+"["+v.Tag+"]")
+		}
+		return arr
+	}
+
+	// Ensure the changesets are applied up to and including the tag.
+	err := r.MigrateTo("v1")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1[]", "release-1[v1]"}, ids())
+
+	// Ensure the changesets are applied up to and including the changeset.
+	err = r.MigrateTo("josephspurrier:2")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1[]", "release-1[v1]", "2[]"}, ids())
+
+	// Ensure a dry run writes the tag.
+	buf := new(bytes.Buffer)
+	r.DryRun = buf
+	err = r.MigrateTo("v2")
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), "'v2');")
+	r.DryRun = nil
+
+	err = r.Migrate(0)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1[]", "release-1[v1]", "2[]", "release-2[v2]", "3[]"}, ids())
+
+	// Ensure the tag from the file can be rolled back to.
+	err = r.Rollback("v1")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1[]", "release-1[v1]"}, ids())
+
+	// Ensure the target must be in the file.
+	err = r.MigrateTo("v3")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "target not found")
+	assert.NotNil(t, r.MigrateTo(""))
+
+	// Ensure a changeset only has one tag.
+	for _, v := range []string{
+		"--changeset josephspurrier:1\n--tagDatabase v1 v2",
+		"--changeset josephspurrier:1\n--tagDatabase v1\n--tagDatabase v2",
+	} {
+		err = rove.NewChangesetMigration(newSQLite(t), v).Migrate(0)
+		assert.True(t, errors.Is(err, rove.ErrInvalidTag), v)
+	}
+}
+
+func TestSQLiteMarkApplied(t *testing.T) {
+	s := newSQLite(t)
+
+	// Create the table that a dump would have created.
+	_, err := s.DB.Exec(`CREATE TABLE a (id INTEGER)`)
+	assert.Nil(t, err)
+
+	r := rove.NewChangesetMigration(s, `--changeset josephspurrier:1
+CREATE TABLE a (id INTEGER);
+--rollback DROP TABLE a;
+
+--changeset josephspurrier:release-1
+--tagDatabase v1
+
+--changeset josephspurrier:2
+CREATE TABLE b (id INTEGER);
+--rollback DROP TABLE b;`)
+	r.Verbose = true
+
+	// Ensure a dry run writes the records without inserting them.
+	buf := new(bytes.Buffer)
+	r.DryRun = buf
+	err = r.MarkApplied("v1")
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), "-- Changeset is marked as ran because the changelog is synced.")
+	assert.Contains(t, buf.String(), "'MARK_RAN'")
+	assert.NotContains(t, buf.String(), "CREATE TABLE a")
+	assert.NotContains(t, buf.String(), "josephspurrier:2")
+	r.DryRun = nil
+
+	exists, err := s.ChangelogExists()
+	assert.Nil(t, err)
+	assert.False(t, exists)
+
+	// Ensure the changesets are recorded up to the target without running.
+	err = r.MarkApplied("v1")
+	assert.Nil(t, err)
+
+	records, err := s.Changesets(false)
+	assert.Nil(t, err)
+	arr := make([]string, 0)
+	for _, v := range records {
+		arr = append(arr, v.ID+" "+v.ExecType)
+	}
+	assert.Equal(t, []string{"1 MARK_RAN", "release-1 MARK_RAN"}, arr)
+
+	// Ensure the checksums match so only the rest of the changesets run.
+	st, err := r.Status()
+	assert.Nil(t, err)
+	if assert.NotNil(t, st) {
+		assert.Equal(t, 1, len(st.Pending))
+	}
+
+	err = r.Migrate(0)
+	assert.Nil(t, err)
+
+	// Ensure the changesets already in the changelog are skipped.
+	err = r.MarkApplied("")
+	assert.Nil(t, err)
+	count, err := s.Count()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, count)
+
+	// Ensure the target must be in the file.
+	err = r.MarkApplied("v2")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "target not found")
+}
+
+func TestSQLiteTagChangeset(t *testing.T) {
+	s := newSQLite(t)
+	r := rove.NewFileMigration(s, "testdata/sqlite/success.sql")
+	r.Verbose = true
+
+	err := r.Migrate(0)
+	assert.Nil(t, err)
+
+	// Tag a changeset that is not the latest.
+	err = r.TagChangeset("v1", "josephspurrier", "1", "")
+	assert.Nil(t, err)
+	err = r.TagChangeset("v2", "josephspurrier", "2", "success.sql")
+	assert.Nil(t, err)
+
+	// Ensure the same tag can be applied to the same changeset again.
+	err = r.TagChangeset("v2", "josephspurrier", "2", "")
+	assert.Nil(t, err)
+
+	// Ensure the tag is unique and the changeset exists.
+	err = r.TagChangeset("v1", "josephspurrier", "3", "")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "tag already found in database: v1")
+	err = r.TagChangeset("v3", "josephspurrier", "4", "")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "changeset not found in database: josephspurrier:4")
+	err = r.TagChangeset("v3", "josephspurrier", "3", "other.sql")
+	assert.NotNil(t, err)
+	assert.NotNil(t, r.TagChangeset("", "josephspurrier", "3", ""))
+
+	// Ensure the tags are listed in the order they were applied.
+	tags, err := r.Tags()
+	assert.Nil(t, err)
+	arr := make([]string, 0)
+	for _, v := range tags {
+		arr = append(arr, v.Tag+" "+v.ID)
+	}
+	assert.Equal(t, []string{"v1 1", "v2 2"}, arr)
+
+	// Ensure the rollback is to the tagged changeset.
+	err = r.Rollback("v1")
+	assert.Nil(t, err)
+	st, err := r.Status()
+	assert.Nil(t, err)
+	if assert.NotNil(t, st) {
+		assert.Equal(t, "1", st.Last.ID)
+	}
+}
+
+func TestSQLiteNonTransactional(t *testing.T) {
+	s := newSQLite(t)
+
+	// Set up rove.
+	r := rove.NewFileMigration(nonTransactional{s}, "testdata/sqlite/success.sql")
+	r.Verbose = true
+
+	// Run migration.
+	err := r.Migrate(0)
+	assert.Nil(t, err)
+
+	// Ensure the records are marked as executed.
+	results, err := s.Changesets(false)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(results))
+	for _, rs := range results {
+		assert.Equal(t, changeset.ExecTypeExecuted, rs.ExecType)
+	}
+
+	// Remove all migrations.
+	err = r.Reset(0)
+	assert.Nil(t, err)
+
+	count, err := s.Count()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestSQLitePending(t *testing.T) {
+	s := newSQLite(t)
+
+	// Set up rove.
+	r := rove.NewFileMigration(s, "testdata/sqlite/success.sql")
+	r.Verbose = true
+
+	// Run migration.
+	err := r.Migrate(2)
+	assert.Nil(t, err)
+
+	// Simulate a crash after the changes of the second changeset were
+	// committed, but before the pending record was marked as executed.
+	record, err := s.ChangesetApplied("2", "josephspurrier", "success.sql")
+	assert.Nil(t, err)
+	record.ExecType = changeset.ExecTypePending
+	err = s.Update(*record)
+	assert.Nil(t, err)
+
+	// Ensure the interrupted changeset is not applied again or rolled back.
+	for _, err := range []error{r.Migrate(0), r.Reset(0)} {
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "changeset was interrupted")
+		assert.Contains(t, err.Error(), "mark it as applied with sync: josephspurrier:2:success.sql")
+	}
+	count, err := s.Count()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, count)
+
+	// Ensure a dry run of the sync doesn't mark the changeset as executed.
+	buf := new(bytes.Buffer)
+	r.DryRun = buf
+	err = r.MarkApplied("josephspurrier:2")
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), "-- Changeset josephspurrier:2 (success.sql) is marked as executed")
+	assert.Contains(t, buf.String(), "'EXECUTED'")
+	r.DryRun = nil
+	record, err = s.ChangesetApplied("2", "josephspurrier", "success.sql")
+	assert.Nil(t, err)
+	assert.Equal(t, changeset.ExecTypePending, record.ExecType)
+
+	// Mark the interrupted changeset as executed and apply the rest.
+	err = r.MarkApplied("josephspurrier:2")
+	assert.Nil(t, err)
+	count, err = s.Count()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, count)
+	err = r.Migrate(0)
+	assert.Nil(t, err)
+
+	// Simulate a crash during the rollback of the last changeset.
+	record, err = s.ChangesetApplied("3", "josephspurrier", "success.sql")
+	assert.Nil(t, err)
+	record.ExecType = changeset.ExecTypeRollbackPending
+	err = s.Update(*record)
+	assert.Nil(t, err)
+
+	// Run migration to repair the rollback.
+	err = r.Migrate(0)
+	assert.Nil(t, err)
+
+	// Ensure the records are marked as executed.
+	results, err := s.Changesets(false)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(results))
+	for _, rs := range results {
+		assert.Equal(t, changeset.ExecTypeExecuted, rs.ExecType)
+	}
+
+	// Ensure the data was inserted only once.
+	err = s.DB.Get(&count, `SELECT COUNT(*) FROM user_status`)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, count)
+
+	// Ensure the changesets can be rolled back.
+	err = r.Reset(0)
+	assert.Nil(t, err)
+}
+
+func TestSQLiteLock(t *testing.T) {
+	s := newSQLite(t)
+
+	// Set up rove.
+	r := rove.NewFileMigration(s, "testdata/sqlite/success.sql")
+	r.Verbose = true
+	r.LockWait = 10 * time.Millisecond
+
+	// Lock the changelog from another process.
+	ok, err := s.Lock("other", "host", time.Now().Add(-time.Hour))
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	// Fail to acquire the lock.
+	for _, v := range []error{
+		r.Migrate(0),
+		r.Reset(0),
+		r.Rollback("none"),
+		r.Tag("none"),
+	} {
+		assert.NotNil(t, v)
+		assert.Contains(t, v.Error(), "could not acquire lock")
+	}
+
+	// Show the lock.
+	l, err := r.LockStatus()
+	assert.Nil(t, err)
+	assert.Equal(t, "other", l.Owner)
+
+	// Release the stale lock.
+	r.LockStale = time.Minute
+	err = r.Migrate(0)
+	assert.Nil(t, err)
+
+	// Ensure the lock was released after the migration.
+	l, err = r.LockStatus()
+	assert.Nil(t, err)
+	assert.Nil(t, l)
+
+	// Lock the changelog from another process and then release it.
+	ok, err = s.Lock("other", "host", time.Now())
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	err = r.ReleaseLock()
+	assert.Nil(t, err)
+	err = r.Reset(0)
+	assert.Nil(t, err)
+}
+
+func TestSQLiteDryRun(t *testing.T) {
+	s := newSQLite(t)
+
+	// Set up rove.
+	buf := new(bytes.Buffer)
+	r := rove.NewFileMigration(s, "testdata/sqlite/success.sql")
+	r.DryRun = buf
+
+	// Output the migration.
+	err := r.Migrate(0)
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), "-- Changeset josephspurrier:1 (success.sql)")
+	assert.Contains(t, buf.String(), "CREATE TABLE user_status")
+	assert.Contains(t, buf.String(), "INSERT INTO rovechangelog")
+
+	// Ensure nothing was applied and the changelog was not created.
+	exists, err := s.ChangelogExists()
+	assert.Nil(t, err)
+	assert.False(t, exists)
+
+	// Ensure there is nothing to rollback without the changelog.
+	buf.Reset()
+	err = r.Reset(0)
+	assert.Nil(t, err)
+	err = r.Rollback("v1")
+	assert.Nil(t, err)
+	err = r.RollbackToDate(time.Now())
+	assert.Nil(t, err)
+	err = r.RollbackToChangeset("josephspurrier", "1", "success.sql")
+	assert.Nil(t, err)
+	assert.Equal(t, "", buf.String())
+
+	// Output the migration again.
+	err = r.Migrate(0)
+	assert.Nil(t, err)
+
+	// Run the output and ensure the changesets were applied.
+	err = s.Initialize()
+	assert.Nil(t, err)
+	_, err = s.DB.Exec(buf.String())
+	assert.Nil(t, err)
+	r.DryRun = nil
+	err = r.Migrate(0)
+	assert.Nil(t, err)
+	count := 0
+	err = s.DB.Get(&count, `SELECT COUNT(*) FROM user_status`)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, count)
+
+	// Output the rollback of 1 changeset.
+	buf.Reset()
+	r.DryRun = buf
+	err = r.Reset(1)
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), "-- Rollback josephspurrier:3 (success.sql)")
+	assert.Contains(t, buf.String(), "DROP TABLE user;")
+	assert.Contains(t, buf.String(), "DELETE FROM rovechangelog")
+	assert.NotContains(t, buf.String(), "josephspurrier:2")
+
+	// Run the output and ensure the changeset was removed.
+	_, err = s.DB.Exec(buf.String())
+	assert.Nil(t, err)
+	count, err = s.Count()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestSQLiteStatus(t *testing.T) {
+	s := newSQLite(t)
+
+	// Set up rove.
+	r := rove.NewFileMigration(s, "testdata/sqlite/success.sql")
+	r.Verbose = true
+
+	// Ensure the status of an empty database has all the changesets pending.
+	st, err := r.Status()
+	assert.Nil(t, err)
+	assert.False(t, st.Current())
+	assert.Equal(t, 0, len(st.Applied))
+	assert.Equal(t, 3, len(st.Pending))
+	assert.Nil(t, st.Last)
+
+	// Run 2 migrations.
+	err = r.Migrate(2)
+	assert.Nil(t, err)
+
+	// Get the status.
+	st, err = r.Status()
+	assert.Nil(t, err)
+	assert.False(t, st.Current())
+	assert.Equal(t, 2, len(st.Applied))
+	assert.Equal(t, 1, len(st.Pending))
+	assert.Equal(t, "3", st.Pending[0].ID)
+	assert.Equal(t, "2", st.Last.ID)
+
+	// Change a checksum and add a changeset that's not in the file.
+	_, err = s.DB.Exec(`UPDATE rovechangelog SET checksum = 'changed' WHERE id = '1'`)
+	assert.Nil(t, err)
+	err = s.Insert(changeset.Record{
+		ID:            "4",
+		Author:        "josephspurrier",
+		Filename:      "success.sql",
+		DateExecuted:  time.Now(),
+		OrderExecuted: 3,
+		ExecType:      changeset.ExecTypeExecuted,
+	})
+	assert.Nil(t, err)
+
+	// Get the status.
+	st, err = r.Status()
+	assert.Nil(t, err)
+	assert.False(t, st.Current())
+	assert.Equal(t, 1, len(st.Applied))
+	assert.Equal(t, 1, len(st.Pending))
+	assert.Equal(t, 1, len(st.Changed))
+	assert.Equal(t, "1", st.Changed[0].ID)
+	assert.Equal(t, 1, len(st.Unknown))
+	assert.Equal(t, "4", st.Unknown[0].ID)
+	assert.Equal(t, "4", st.Last.ID)
+
+	// Remove the changes and apply the rest.
+	err = s.Delete("4", "josephspurrier", "success.sql")
+	assert.Nil(t, err)
+	r.Checksum = rove.ChecksumUpdate
+	err = r.Migrate(0)
+	assert.Nil(t, err)
+
+	// Get the status.
+	st, err = r.Status()
+	assert.Nil(t, err)
+	assert.True(t, st.Current())
+	assert.Equal(t, 3, len(st.Applied))
+}
+
+func TestSQLiteChecksumUpgrade(t *testing.T) {
+	s := newSQLite(t)
+
+	// Apply the changesets with MD5 checksums.
+	r := rove.NewFileMigration(s, "testdata/sqlite/success.sql")
+	r.ChecksumVersion = changeset.ChecksumMD5
+	err := r.Migrate(0)
+	assert.Nil(t, err)
+
+	rs, err := s.Changesets(false)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(rs))
+	for _, v := range rs {
+		assert.Equal(t, changeset.ChecksumMD5, changeset.ChecksumVersion(v.Checksum))
+	}
+
+	// Remove the version from the first checksum like a changelog from before
+	// checksums were versioned.
+	legacy := rs[0]
+	legacy.Checksum = strings.TrimPrefix(legacy.Checksum, "1:")
+	err = s.Update(legacy)
+	assert.Nil(t, err)
+
+	// Ensure a dry run doesn't upgrade the checksums.
+	buf := new(bytes.Buffer)
+	r = rove.NewFileMigration(s, "testdata/sqlite/success.sql")
+	r.DryRun = buf
+	err = r.Migrate(0)
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), "Upgrade checksum from ("+legacy.Checksum+") to (2:")
+
+	// Ensure the checksums are verified and upgraded to the default version.
+	r = rove.NewFileMigration(s, "testdata/sqlite/success.sql")
+	err = r.Migrate(0)
+	assert.Nil(t, err)
+
+	rs, err = s.Changesets(false)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(rs))
+	for _, v := range rs {
+		assert.Equal(t, changeset.ChecksumSHA256, changeset.ChecksumVersion(v.Checksum))
+	}
+
+	st, err := r.Status()
+	assert.Nil(t, err)
+	assert.True(t, st.Current())
+
+	// Ensure a changed changeset is still detected after the upgrade.
+	changed := rs[0]
+	changed.Checksum = "1:bad"
+	err = s.Update(changed)
+	assert.Nil(t, err)
+	err = r.Migrate(0)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "checksum does not match")
+
+	// Ensure an unknown version is an error.
+	changed.Checksum = "99:bad"
+	err = s.Update(changed)
+	assert.Nil(t, err)
+	err = r.Migrate(0)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "unknown checksum version: 99")
+
+	r.ChecksumVersion = "99"
+	_, err = r.Status()
+	assert.NotNil(t, err)
+}
+
+func TestSQLiteChecksumNormalize(t *testing.T) {
+	s := newSQLite(t)
+
+	r := rove.NewChangesetMigration(s, `--changeset josephspurrier:1
+CREATE TABLE user_status (id INTEGER PRIMARY KEY, status TEXT);
+--changeset josephspurrier:2
+INSERT INTO user_status (id, status) VALUES (1, 'active');`)
+	r.ChecksumNormalize = true
+	err := r.Migrate(0)
+	assert.Nil(t, err)
+
+	rs, err := s.Changesets(false)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(rs))
+	assert.Equal(t, "2n", changeset.ChecksumVersion(rs[0].Checksum))
+
+	// Ensure reformatting the SQL doesn't change the checksum.
+	r = rove.NewChangesetMigration(s, "--changeset josephspurrier:1\r\n"+
+		"CREATE TABLE user_status (\r\n"+
+		"    id INTEGER PRIMARY KEY, -- The ID.\r\n"+
+		"    status TEXT\r\n"+
+		");\r\n"+
+		"--changeset josephspurrier:2\r\n"+
+		"INSERT INTO user_status (id, status)\r\n"+
+		"VALUES (1, 'active');   \r\n")
+	r.ChecksumNormalize = true
+	st, err := r.Status()
+	assert.Nil(t, err)
+	assert.True(t, st.Current())
+	err = r.Migrate(0)
+	assert.Nil(t, err)
+
+	// Ensure a change in a string is detected.
+	r = rove.NewChangesetMigration(s, `--changeset josephspurrier:1
+CREATE TABLE user_status (id INTEGER PRIMARY KEY, status TEXT);
+--changeset josephspurrier:2
+INSERT INTO user_status (id, status) VALUES (1, 'active ');`)
+	r.ChecksumNormalize = true
+	err = r.Migrate(0)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "checksum does not match")
+}
+
+func TestSQLiteValidChecksum(t *testing.T) {
+	s := newSQLite(t)
+
+	r := rove.NewChangesetMigration(s, `--changeset josephspurrier:1
+CREATE TABLE user_status (id INTEGER PRIMARY KEY, status TEXT);
+--changeset josephspurrier:2
+INSERT INTO user_status (id, status) VALUES (1, 'active');`)
+	err := r.Migrate(0)
+	assert.Nil(t, err)
+
+	rs, err := s.Changesets(false)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(rs))
+	old := rs[1].Checksum
+
+	// Ensure the old checksum is accepted for the fixed changeset only.
+	fixed := `--changeset josephspurrier:1
+CREATE TABLE user_status (id INTEGER PRIMARY KEY, status TEXT);
+--changeset josephspurrier:2
+--validCheckSum ` + old + `
+INSERT INTO user_status (id, status) VALUES (1, 'inactive');`
+	r = rove.NewChangesetMigration(s, fixed)
+	err = r.Migrate(0)
+	assert.Nil(t, err)
+
+	st, err := r.Status()
+	assert.Nil(t, err)
+	assert.True(t, st.Current())
+
+	r = rove.NewChangesetMigration(s, `--changeset josephspurrier:1
+CREATE TABLE user_status (id INTEGER PRIMARY KEY, status BLOB);
+--changeset josephspurrier:2
+--validCheckSum `+old+`
+INSERT INTO user_status (id, status) VALUES (1, 'inactive');`)
+	err = r.Migrate(0)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "checksum does not match - existing changeset josephspurrier:1")
+
+	// Ensure the old checksum is kept unless the checksums are updated.
+	rs, err = s.Changesets(false)
+	assert.Nil(t, err)
+	assert.Equal(t, old, rs[1].Checksum)
+
+	r = rove.NewChangesetMigration(s, fixed)
+	r.Checksum = rove.ChecksumUpdate
+	err = r.Migrate(0)
+	assert.Nil(t, err)
+
+	rs, err = s.Changesets(false)
+	assert.Nil(t, err)
+	assert.NotEqual(t, old, rs[1].Checksum)
+}
+
+func TestSQLiteProperties(t *testing.T) {
+	s := newSQLite(t)
+
+	migration := `--property table=user_status
+--property column=status
+--changeset josephspurrier:1
+CREATE TABLE ${table} (id INTEGER PRIMARY KEY, ${column} TEXT);
+--rollback DROP TABLE ${table};
+--changeset josephspurrier:2
+--precondition-column-exists tableName:${table} columnName:${column}
+INSERT INTO ${table} (id, ${column}) VALUES (1, '${value}');
+--rollback DELETE FROM ${table};
+--property table=ignored`
+
+	os.Setenv("ROVE_TEST_VALUE", "active")
+	defer os.Unsetenv("ROVE_TEST_VALUE")
+
+	// Ensure the properties are from the Properties, the file, and then the
+	// environment variables.
+	r := rove.NewChangesetMigration(s, migration)
+	r.Properties = map[string]string{"column": "state"}
+	r.EnvPrefix = "ROVE_TEST_"
+	err := r.Migrate(0)
+	assert.Nil(t, err)
+
+	v, err := s.QueryValue("SELECT state FROM user_status WHERE id = 1")
+	assert.Nil(t, err)
+	assert.Equal(t, "active", v)
+
+	// Ensure the checksum is of the text before the properties are substituted.
+	rs, err := s.Changesets(false)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(rs))
+	cs := new(changeset.Record)
+	cs.AddChange("INSERT INTO ${table} (id, ${column}) VALUES (1, '${value}');")
+	assert.Equal(t, cs.GenerateChecksum(), rs[1].Checksum)
+
+	// Ensure the rollbacks have the properties.
+	err = r.Reset(0)
+	assert.Nil(t, err)
+	exists, err := s.TableExists("user_status")
+	assert.Nil(t, err)
+	assert.False(t, exists)
+
+	// Ensure an undefined property is an error.
+	r = rove.NewChangesetMigration(s, migration)
+	err = r.Migrate(0)
+	assert.NotNil(t, err)
+	assert.True(t, errors.Is(err, changeset.ErrUndefinedProperty))
+	assert.Contains(t, err.Error(), "error on changeset josephspurrier:2 - property is not defined: value")
+
+	// Ensure an invalid property is an error.
+	r = rove.NewChangesetMigration(s, "--property table\n"+migration)
+	err = r.Migrate(0)
+	assert.True(t, errors.Is(err, rove.ErrInvalidProperty))
+}
+
+func TestSQLiteContexts(t *testing.T) {
+	s := newSQLite(t)
+
+	// Set up rove.
+	r := rove.NewFileMigration(s, "testdata/sqlite/contexts.sql")
+	r.Verbose = true
+
+	// Ensure invalid expressions return an error.
+	r.Contexts = "dev and"
+	err := r.Migrate(0)
+	assert.NotNil(t, err)
+	r.Contexts = ""
+	r.Labels = "(seed"
+	err = r.Migrate(0)
+	assert.NotNil(t, err)
+
+	// Apply the changesets for dev without seed data.
+	r.Contexts = "dev"
+	r.Labels = "!seed"
+	err = r.Migrate(0)
+	assert.Nil(t, err)
+	count, err := s.Count()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+
+	// Ensure skipped changesets are not pending.
+	st, err := r.Status()
+	assert.Nil(t, err)
+	assert.True(t, st.Current())
+
+	// Apply the changesets for dev.
+	r.Labels = ""
+	err = r.Migrate(0)
+	assert.Nil(t, err)
+
+	// Ensure the contexts and labels are recorded.
+	record, err := s.ChangesetApplied("2", "josephspurrier", "contexts.sql")
+	assert.Nil(t, err)
+	assert.Equal(t, "dev,test", record.Contexts)
+	assert.Equal(t, "seed", record.Labels)
+
+	// Ensure the changeset for test was skipped.
+	record, err = s.ChangesetApplied("3", "josephspurrier", "contexts.sql")
+	assert.Nil(t, err)
+	assert.Nil(t, record)
+
+	// Apply the changesets for test.
+	r.Contexts = "test or prod"
+	err = r.Migrate(0)
+	assert.Nil(t, err)
+	err = s.DB.Get(&count, `SELECT COUNT(*) FROM user_status`)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, count)
+
+	// Ensure the applied changesets for test are not unknown for dev.
+	r.Contexts = "dev"
+	st, err = r.Status()
+	assert.Nil(t, err)
+	assert.True(t, st.Current())
+	assert.Equal(t, 3, len(st.Applied))
+	assert.Equal(t, 0, len(st.Unknown))
+}
+
+func TestSQLitePreconditions(t *testing.T) {
+	s := newSQLite(t)
+
+	// Set up rove.
+	r := rove.NewFileMigration(s, "testdata/sqlite/preconditions.sql")
+	r.Verbose = true
+
+	// Run migration.
+	err := r.Migrate(0)
+	assert.Nil(t, err)
+
+	// Ensure the changeset was marked as ran.
+	record, err := s.ChangesetApplied("2", "josephspurrier", "preconditions.sql")
+	assert.Nil(t, err)
+	assert.Equal(t, changeset.ExecTypeMarkRan, record.ExecType)
+
+	// Ensure the changeset was applied after the warning.
+	record, err = s.ChangesetApplied("4", "josephspurrier", "preconditions.sql")
+	assert.Nil(t, err)
+	assert.Equal(t, changeset.ExecTypeExecuted, record.ExecType)
+
+	// Ensure the changesets that continued are still pending.
+	st, err := r.Status()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(st.Applied))
+	assert.Equal(t, 2, len(st.Pending))
+	assert.Equal(t, "3", st.Pending[0].ID)
+	assert.Equal(t, "5", st.Pending[1].ID)
+
+	// Ensure the changeset is applied once the precondition passes.
+	err = r.Migrate(0)
+	assert.Nil(t, err)
+	record, err = s.ChangesetApplied("3", "josephspurrier", "preconditions.sql")
+	assert.Nil(t, err)
+	assert.NotNil(t, record)
+
+	// Remove all migrations without running the rollback of the changeset
+	// that was marked as ran.
+	err = r.Reset(0)
+	assert.Nil(t, err)
+	count, err := s.Count()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestSQLitePreconditionErrors(t *testing.T) {
+	for _, v := range []string{
+		"--precondition-sql-check SELECT 1",
+		"--precondition-sql-check expectedResult:1",
+		"--precondition-table-exists expectedResult:true",
+		"--precondition-table-exists tableName:user expectedResult:yes",
+		"--precondition-column-exists tableName:user",
+		"--preconditions onFail:STOP",
+		"--preconditions onSuccess:HALT",
+	} {
+		r := rove.NewChangesetMigration(newSQLite(t), "--changeset josephspurrier:1\n"+
+			v+"\nSELECT 1;")
+		err := r.Migrate(0)
+		assert.NotNil(t, err, v)
+		assert.Contains(t, err.Error(), "invalid precondition", v)
+	}
+
+	// Ensure a failed precondition halts by default.
+	r := rove.NewChangesetMigration(newSQLite(t), `--changeset josephspurrier:1
+--precondition-table-exists tableName:user
+SELECT 1;`)
+	err := r.Migrate(0)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "precondition failed")
+
+	// Ensure an error halts by default.
+	r = rove.NewChangesetMigration(newSQLite(t), `--changeset josephspurrier:1
+--preconditions onFail:CONTINUE
+--precondition-sql-check expectedResult:0 SELECT COUNT(*) FROM user
+SELECT 1;`)
+	err = r.Migrate(0)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "error on precondition")
+}
+
+func TestSQLiteParseError(t *testing.T) {
+	for _, v := range []struct {
+		changeset string
+		line      int
+		column    int
+		snippet   string
+		err       string
+	}{
+		{"SELECT 1;", 1, 1, "SELECT 1;", "invalid changeset format"},
+		{"--changeset josephspurrier:1\nSELECT 1;\n  --changeset josephspurrier",
+			3, 15, "--changeset josephspurrier", "invalid changeset header"},
+		{"--changeset josephspurrier:1 runAlways:yes\nSELECT 1;",
+			1, 13, "--changeset josephspurrier:1 runAlways:yes", "invalid changeset header"},
+		{"--changeset josephspurrier:1 runOnchange:true\nSELECT 1;",
+			1, 13, "--changeset josephspurrier:1 runOnchange:true", "invalid changeset header - unknown attribute: runOnchange"},
+		{"--changeset josephspurrier:1 runalways:true\nSELECT 1;",
+			1, 13, "--changeset josephspurrier:1 runalways:true", "invalid changeset header - unknown attribute: runalways"},
+		{"--changeset josephspurrier:1\n--precondition-sql-check SELECT 1\nSELECT 1;",
+			2, 1, "--precondition-sql-check SELECT 1", "invalid precondition"},
+		{"--changeset josephspurrier:1\nSELECT 1;\n\n--changeset josephspurrier:1\nSELECT 2;",
+			4, 1, "--changeset josephspurrier:1", "duplicate entry found: josephspurrier:1:memory (first defined at memory:1)"},
+		{"--changeset josephspurrier:1\nSELECT 1;\n--include missing.sql",
+			3, 11, "--include missing.sql", "open missing.sql"},
+	} {
+		r := rove.NewChangesetMigration(newSQLite(t), v.changeset)
+		err := r.Migrate(0)
+		assert.NotNil(t, err, v.changeset)
+
+		var pe *rove.ParseError
+		if assert.True(t, errors.As(err, &pe), v.changeset) {
+			assert.Equal(t, "memory", pe.File)
+			assert.Equal(t, v.line, pe.Line, v.changeset)
+			assert.Equal(t, v.column, pe.Column, v.changeset)
+			assert.Equal(t, v.snippet, pe.Snippet, v.changeset)
+			assert.Contains(t, pe.Err.Error(), v.err, v.changeset)
+		}
+	}
+
+	// Ensure an error in an included file has the location in that file.
+	r := rove.NewFSMigration(newSQLite(t), fstest.MapFS{
+		"parent.sql": &fstest.MapFile{Data: []byte("--include child.sql")},
+		"child.sql":  &fstest.MapFile{Data: []byte("--changeset josephspurrier:1\nSELECT 1;\n--changeset bad")},
+	}, "parent.sql")
+	err := r.Migrate(0)
+	var pe *rove.ParseError
+	if assert.True(t, errors.As(err, &pe)) {
+		assert.Equal(t, "child.sql", pe.File)
+		assert.Equal(t, 3, pe.Line)
+		assert.Equal(t, "child.sql:3:13: invalid changeset header: --changeset bad", pe.Error())
+	}
+}
+
+func TestSQLiteLongLine(t *testing.T) {
+	s := newSQLite(t)
+
+	// Create a single line insert larger than the default bufio.Scanner limit.
+	values := make([]string, 0)
+	for i := 1; i <= 20000; i++ {
+		values = append(values, fmt.Sprintf("(%v, 'status %v')", i, i))
+	}
+	insert := "INSERT INTO user_status (id, status) VALUES " +
+		strings.Join(values, ", ") + ";"
+	assert.True(t, len(insert) > bufio.MaxScanTokenSize)
+
+	r := rove.NewChangesetMigration(s, `--changeset josephspurrier:1
+CREATE TABLE user_status (id INTEGER PRIMARY KEY, status TEXT);
+--changeset josephspurrier:2
+`+insert+"\r\n--rollback DELETE FROM user_status;")
+	err := r.Migrate(0)
+	assert.Nil(t, err)
+
+	var count string
+	count, err = s.QueryValue("SELECT COUNT(*) FROM user_status")
+	assert.Nil(t, err)
+	assert.Equal(t, "20000", count)
+
+	// Ensure the checksum is of the whole line.
+	rs, err := s.Changesets(false)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(rs))
+	assert.Equal(t, fmt.Sprintf("2:%x", sha256.Sum256([]byte(insert))), rs[1].Checksum)
+
+	// Ensure a long line in an error is truncated.
+	r = rove.NewChangesetMigration(newSQLite(t), insert)
+	err = r.Migrate(0)
+	var pe *rove.ParseError
+	if assert.True(t, errors.As(err, &pe)) {
+		assert.Equal(t, insert[:200]+"...", pe.Snippet)
+	}
+}
+
+func TestSQLiteRerun(t *testing.T) {
+	s := newSQLite(t)
+
+	b, err := ioutil.ReadFile("testdata/sqlite/rerun.sql")
+	assert.Nil(t, err)
+
+	// Set up rove.
+	r := rove.NewChangesetMigration(s, string(b))
+	r.Verbose = true
+
+	// Run migration twice.
+	err = r.Migrate(0)
+	assert.Nil(t, err)
+	err = r.Migrate(0)
+	assert.Nil(t, err)
+
+	// Ensure the changeset that runs always was applied again.
+	record, err := s.ChangesetApplied("3", "josephspurrier", "memory")
+	assert.Nil(t, err)
+	assert.Equal(t, changeset.ExecTypeReran, record.ExecType)
+	assert.Equal(t, 1, record.Reruns)
+	assert.False(t, record.LastRerun.IsZero())
+	assert.Equal(t, 3, record.OrderExecuted)
+	count := 0
+	err = s.DB.Get(&count, `SELECT COUNT(*) FROM user_status`)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, count)
+
+	// Ensure the changeset that runs on change was not applied again.
+	record, err = s.ChangesetApplied("2", "josephspurrier", "memory")
+	assert.Nil(t, err)
+	assert.Equal(t, changeset.ExecTypeExecuted, record.ExecType)
+	assert.Equal(t, 0, record.Reruns)
+	assert.True(t, record.LastRerun.IsZero())
+	checksum := record.Checksum
+
+	// Change the changesets.
+	changed := strings.Replace(string(b), "status = 'active'", "status != 'inactive'", 1)
+	r = rove.NewChangesetMigration(s, changed)
+	r.Verbose = true
+
+	// Ensure the changed changeset is pending.
+	st, err := r.Status()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(st.Pending))
+	assert.Equal(t, 0, len(st.Changed))
+
+	// Ensure the changeset that runs on change is applied again.
+	err = r.Migrate(0)
+	assert.Nil(t, err)
+	record, err = s.ChangesetApplied("2", "josephspurrier", "memory")
+	assert.Nil(t, err)
+	assert.Equal(t, changeset.ExecTypeReran, record.ExecType)
+	assert.Equal(t, 1, record.Reruns)
+	assert.NotEqual(t, checksum, record.Checksum)
+
+	// Ensure a changed changeset that runs always still returns an error.
+	changed = strings.Replace(changed, "('active')", "('inactive')", 1)
+	r = rove.NewChangesetMigration(s, changed)
+	err = r.Migrate(0)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "checksum does not match")
+
+	// Ensure the output is written on a dry run.
+	buf := new(bytes.Buffer)
+	r = rove.NewChangesetMigration(s, string(b))
+	r.Checksum = rove.ChecksumIgnore
+	r.DryRun = buf
+	err = r.Migrate(0)
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), "-- Changeset is applied again (rerun 2).")
+	assert.Contains(t, buf.String(), "reruns = 2")
+}
+
+func TestSQLiteStatements(t *testing.T) {
+	s := newSQLite(t)
+
+	// Set up rove.
+	r := rove.NewChangesetMigration(s, `--changeset josephspurrier:1
+CREATE TABLE user_status (
+    id INTEGER NOT NULL PRIMARY KEY,
+    status VARCHAR(25) NOT NULL
+);
+INSERT INTO user_status (id, status) VALUES (1, 'active;');
+--rollback DROP TABLE user_status;
+
+--changeset josephspurrier:2
+INSERT INTO user_status (id, status) VALUES (2, 'inactive');
+INSERT INTO missing (id) VALUES (1);`)
+	r.Verbose = true
+
+	// Ensure the failed statement is reported.
+	err := r.Migrate(0)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "error on changeset josephspurrier:2")
+	assert.Contains(t, err.Error(), "statement 2 (line 2)")
+
+	// Ensure each statement of the first changeset ran and the failed
+	// changeset was rolled back.
+	count := 0
+	err = s.DB.Get(&count, `SELECT COUNT(*) FROM user_status WHERE status = 'active;'`)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+	err = s.DB.Get(&count, `SELECT COUNT(*) FROM user_status`)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+}
@@ -6,35 +6,144 @@ import (
 	"github.com/josephspurrier/rove/pkg/changeset"
 )
 
-// Status will output all changesets from the database table and will return
-// an error, the last changeset, or a blank string. Check in that order.
-func (r *Rove) Status() (*changeset.Record, error) {
-	var last *changeset.Record
+// StatusReport is a comparison of the changesets with the changelog.
+type StatusReport struct {
+	// Applied are the changesets applied to the database with a matching
+	// checksum.
+	Applied []changeset.Record
+	// Pending are the changesets not yet applied to the database, that were
+	// interrupted, or that run on change and have changed.
+	Pending []changeset.Record
+	// Changed are the changesets applied to the database with a checksum that
+	// no longer matches the changeset.
+	Changed []changeset.Record
+	// Unknown are the changesets applied to the database that are missing
+	// from the changesets.
+	Unknown []changeset.Record
+	// Last is the last changeset applied to the database or nil if none are
+	// applied.
+	Last *changeset.Record
+}
 
-	// Get an array of changesets from the database.
-	results, err := r.db.Changesets(false)
+// Current returns true if there are no pending, changed, or unknown
+// changesets.
+func (s *StatusReport) Current() bool {
+	return len(s.Pending) == 0 && len(s.Changed) == 0 && len(s.Unknown) == 0
+}
+
+// Status will compare the changesets with the changelog and return a report
+// or an error. The records in the report are from the changelog except for
+// the pending changesets that are not in the changelog yet.
+func (r *Rove) Status() (*StatusReport, error) {
+	// A missing changelog has no changesets applied.
+	exists, err := r.changelogExists()
 	if err != nil {
 		return nil, err
 	}
 
-	if len(results) == 0 {
-		if r.Verbose {
-			fmt.Println("No changesets applied to the database.")
+	// Get an array of changesets from the database.
+	var results []changeset.Record
+	if exists {
+		results, err = r.db.Changesets(false)
+		if err != nil {
+			return nil, err
 		}
-		return nil, nil
 	}
 
-	if r.Verbose {
-		fmt.Println("Changesets applied:")
+	// Get all the changesets so the applied changesets that don't match the
+	// contexts and labels are not unknown.
+	arr, properties, err := r.parseChangesetArray()
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the changesets that match the contexts and labels are pending.
+	filtered, err := r.filter(arr)
+	if err != nil {
+		return nil, err
+	}
+	included := make(map[string]bool)
+	for _, cs := range filtered {
+		included[fmt.Sprintf("%v:%v:%v", cs.Author, cs.ID, cs.Filename)] = true
+	}
+
+	s := new(StatusReport)
+
+	// Map the records by the changeset ID.
+	records := make(map[string]changeset.Record)
+	for i, rs := range results {
+		records[fmt.Sprintf("%v:%v:%v", rs.Author, rs.ID, rs.Filename)] = rs
+		s.Last = &results[i]
 	}
 
 	// Loop through each changeset.
+	for _, cs := range arr {
+		id := fmt.Sprintf("%v:%v:%v", cs.Author, cs.ID, cs.Filename)
+		rs, ok := records[id]
+		delete(records, id)
+
+		changed := false
+		if ok {
+			changed, err = checksumChanged(cs, rs)
+			if err != nil {
+				return nil, err
+			}
+			changed = changed && !cs.IsValidChecksum(rs.Checksum)
+		}
+
+		if !ok && included[id] {
+			err = r.substitute(&cs, properties)
+			if err != nil {
+				return nil, err
+			}
+			s.Pending = append(s.Pending, cs)
+		} else if !ok {
+			continue
+		} else if rs.ExecType == changeset.ExecTypePending ||
+			rs.ExecType == changeset.ExecTypeRollbackPending {
+			s.Pending = append(s.Pending, rs)
+		} else if changed && cs.RunOnChange {
+			s.Pending = append(s.Pending, rs)
+		} else if changed {
+			s.Changed = append(s.Changed, rs)
+		} else {
+			s.Applied = append(s.Applied, rs)
+		}
+	}
+
+	// The remaining records are not in the changesets.
 	for _, rs := range results {
-		last = &rs
-		if r.Verbose {
-			fmt.Printf("%v\n", rs.String())
+		if _, ok := records[fmt.Sprintf("%v:%v:%v", rs.Author, rs.ID, rs.Filename)]; ok {
+			s.Unknown = append(s.Unknown, rs)
 		}
 	}
 
-	return last, nil
+	if r.Verbose {
+		s.print()
+	}
+
+	return s, nil
+}
+
+// print will output each group of changesets in the report.
+func (s *StatusReport) print() {
+	for _, g := range []struct {
+		name    string
+		records []changeset.Record
+	}{
+		{"applied", s.Applied},
+		{"pending", s.Pending},
+		{"applied with a changed checksum", s.Changed},
+		{"applied but missing from the file", s.Unknown},
+	} {
+		if len(g.records) == 0 {
+			fmt.Printf("No changesets %v.\n", g.name)
+			continue
+		}
+
+		fmt.Printf("Changesets %v:\n", g.name)
+		for _, rs := range g.records {
+			fmt.Printf("%v\n", rs.String())
+		}
+	}
 }
@@ -0,0 +1,115 @@
+package rove
+
+import (
+	"fmt"
+
+	"github.com/josephspurrier/rove/pkg/changeset"
+)
+
+// MarkApplied will record the changesets in a file in the changelog without
+// running them so a database built another way, like from a dump, can be
+// migrated from that point. The changesets are recorded up to and including
+// the target, which is a tag from a tagDatabase in the file or a changeset in
+// the format author:id. If the target is blank, all the changesets are
+// recorded. Changesets already in the changelog are skipped except for the
+// changesets that were interrupted, which are marked as executed once the
+// database is fixed. If DryRun is set, the SQL is written to it instead.
+func (r *Rove) MarkApplied(target string) (err error) {
+	// Prevent other processes from changing the changelog.
+	release, err := r.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock(release, &err)
+
+	// Create the object to store the changeset log.
+	err = r.initialize()
+	if err != nil {
+		return err
+	}
+
+	// Get the changesets.
+	arr, err := r.loadChangesetArray()
+	if err != nil {
+		return err
+	}
+
+	// Only record the changesets up to and including the target.
+	if len(target) > 0 {
+		i, err := targetIndex(arr, target)
+		if err != nil {
+			return err
+		}
+		arr = arr[:i+1]
+	}
+
+	if r.Verbose {
+		if len(target) > 0 {
+			fmt.Printf("Changesets marked as ran (target: %v):\n", target)
+		} else {
+			fmt.Println("Changesets marked as ran:")
+		}
+	}
+
+	inserted := 0
+
+	// Loop through each changeset.
+	for _, cs := range arr {
+		// Skip the changesets already in the changelog.
+		record, err := r.changesetApplied(cs)
+		if err != nil {
+			return fmt.Errorf("internal error on changeset %v:%v - %v", cs.Author, cs.ID, err.Error())
+		}
+		if record != nil && record.ExecType == changeset.ExecTypePending {
+			err = r.markExecuted(cs, *record)
+			if err != nil {
+				return err
+			}
+			continue
+		} else if record != nil {
+			if r.Verbose {
+				fmt.Printf("Already applied: %v\n", record.String())
+			}
+			continue
+		}
+
+		err = r.markRan(cs, inserted, "the changelog is synced")
+		if err != nil {
+			return err
+		}
+		if r.DryRun != nil {
+			inserted++
+		}
+	}
+
+	return nil
+}
+
+// markExecuted will mark the pending record of an interrupted changeset as
+// executed without running the changeset again.
+func (r *Rove) markExecuted(cs changeset.Record, pending changeset.Record) error {
+	checksum, err := r.checksum(cs)
+	if err != nil {
+		return err
+	}
+
+	record := pending
+	record.Checksum = checksum
+	record.ExecType = changeset.ExecTypeExecuted
+
+	// Write the update instead of running it on a dry run.
+	if r.DryRun != nil {
+		return r.writeUpdate(record, "is marked as executed because the changelog is synced")
+	}
+
+	err = r.db.Update(record)
+	if err != nil {
+		return fmt.Errorf("error on updating changelog record: %v", err)
+	}
+
+	if r.Verbose {
+		fmt.Printf("Marked as executed: %v\n", record.String())
+	}
+
+	return nil
+}
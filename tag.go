@@ -3,14 +3,23 @@ package rove
 import (
 	"errors"
 	"fmt"
+
+	"github.com/josephspurrier/rove/pkg/changeset"
 )
 
 // Tag will tag the latest changelog to allow for rollbacks to a tag.
-func (r *Rove) Tag(tag string) error {
+func (r *Rove) Tag(tag string) (err error) {
 	if len(tag) == 0 {
 		return fmt.Errorf("error - tag cannot be empty")
 	}
 
+	// Prevent other processes from changing the changelog.
+	release, err := r.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock(release, &err)
+
 	// Get an array of changesets from the database.
 	results, err := r.db.Changesets(true)
 	if err != nil {
@@ -36,8 +45,63 @@ func (r *Rove) Tag(tag string) error {
 		return errors.New("changeset is missing: " + id)
 	}
 
+	return r.tag(results, rs, tag)
+}
+
+// TagChangeset will tag the changeset in the changelog to allow for rollbacks
+// to a tag. If the filename is blank, the changeset must be the only changeset
+// in the changelog with the author and id.
+func (r *Rove) TagChangeset(tag, author, id, filename string) (err error) {
+	if len(tag) == 0 {
+		return fmt.Errorf("error - tag cannot be empty")
+	}
+
+	// Prevent other processes from changing the changelog.
+	release, err := r.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock(release, &err)
+
+	// Get an array of changesets from the database.
+	results, err := r.db.Changesets(true)
+	if err != nil {
+		return err
+	}
+
+	// Find the changeset in the changelog.
+	found := make([]changeset.Record, 0)
+	for _, rs := range results {
+		if rs.Author == author && rs.ID == id &&
+			(len(filename) == 0 || rs.Filename == filename) {
+			found = append(found, rs)
+		}
+	}
+
+	name := fmt.Sprintf("%v:%v", author, id)
+	if len(filename) > 0 {
+		name += ":" + filename
+	}
+
+	if len(found) == 0 {
+		return errors.New("changeset not found in database: " + name)
+	} else if len(found) > 1 {
+		return fmt.Errorf("changeset found in more than one file, include the filename: %v", name)
+	}
+
+	return r.tag(results, found[0], tag)
+}
+
+// tag will tag the record if the tag is not on another record in the results.
+func (r *Rove) tag(results []changeset.Record, rs changeset.Record, tag string) error {
+	for _, v := range results {
+		if v.Tag == tag && (v.Author != rs.Author || v.ID != rs.ID || v.Filename != rs.Filename) {
+			return fmt.Errorf("error on tag - tag already found in database: %v", tag)
+		}
+	}
+
 	// Tag the changeset.
-	err = r.db.Tag(rs.ID, rs.Author, rs.Filename, tag)
+	err := r.db.Tag(rs.ID, rs.Author, rs.Filename, tag)
 	if err != nil {
 		return fmt.Errorf("error on tag - %v", err.Error())
 	}
@@ -48,3 +112,31 @@ func (r *Rove) Tag(tag string) error {
 
 	return nil
 }
+
+// Tags returns the changesets with a tag in the order they were applied.
+func (r *Rove) Tags() ([]changeset.Record, error) {
+	// Get an array of changesets from the database.
+	results, err := r.db.Changesets(false)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make([]changeset.Record, 0)
+	for _, rs := range results {
+		if len(rs.Tag) > 0 {
+			tags = append(tags, rs)
+		}
+	}
+
+	if r.Verbose {
+		if len(tags) == 0 {
+			fmt.Println("No tags in the changelog.")
+		}
+		for _, rs := range tags {
+			fmt.Printf("%v: %v) %v:%v (%v) applied %v\n", rs.Tag, rs.OrderExecuted,
+				rs.Author, rs.ID, rs.Filename, rs.DateExecuted.Format("2006-01-02 15:04:05"))
+		}
+	}
+
+	return tags, nil
+}
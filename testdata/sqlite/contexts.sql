@@ -0,0 +1,14 @@
+--changeset josephspurrier:1
+CREATE TABLE user_status (
+    id INTEGER NOT NULL PRIMARY KEY,
+    status VARCHAR(25) NOT NULL
+);
+--rollback DROP TABLE user_status;
+
+--changeset josephspurrier:2 context:dev,test labels:seed
+INSERT INTO user_status (id, status) VALUES (1, 'active');
+--rollback DELETE FROM user_status WHERE id = 1;
+
+--changeset josephspurrier:3 context:test
+INSERT INTO user_status (id, status) VALUES (2, 'inactive');
+--rollback DELETE FROM user_status WHERE id = 2;
@@ -0,0 +1,32 @@
+--changeset josephspurrier:1
+CREATE TABLE user_status (
+    id INTEGER NOT NULL PRIMARY KEY,
+    status VARCHAR(25) NOT NULL
+);
+--rollback DROP TABLE user_status;
+
+--changeset josephspurrier:2
+--preconditions onFail:MARK_RAN
+--precondition-table-exists tableName:user_status expectedResult:false
+CREATE TABLE user_status (
+    id INTEGER NOT NULL PRIMARY KEY
+);
+--rollback DROP TABLE user_status;
+
+--changeset josephspurrier:3
+--preconditions onFail:CONTINUE
+--precondition-sql-check expectedResult:1 SELECT COUNT(*) FROM user_status
+INSERT INTO user_status (id, status) VALUES (2, 'inactive');
+--rollback DELETE FROM user_status WHERE id = 2;
+
+--changeset josephspurrier:4
+--preconditions onFail:WARN
+--precondition-column-exists tableName:user_status columnName:missing
+INSERT INTO user_status (id, status) VALUES (1, 'active');
+--rollback DELETE FROM user_status WHERE id = 1;
+
+--changeset josephspurrier:5
+--preconditions onError:CONTINUE
+--precondition-sql-check expectedResult:0 SELECT COUNT(*) FROM missing
+INSERT INTO user_status (id, status) VALUES (3, 'deleted');
+--rollback DELETE FROM user_status WHERE id = 3;
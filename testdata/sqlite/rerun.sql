@@ -0,0 +1,14 @@
+--changeset josephspurrier:1
+CREATE TABLE user_status (
+    id INTEGER NOT NULL PRIMARY KEY,
+    status VARCHAR(25) NOT NULL
+);
+--rollback DROP TABLE user_status;
+
+--changeset josephspurrier:2 runOnChange:true
+CREATE VIEW IF NOT EXISTS active_status AS SELECT * FROM user_status WHERE status = 'active';
+--rollback DROP VIEW active_status;
+
+--changeset josephspurrier:3 runAlways:true
+INSERT INTO user_status (status) VALUES ('active');
+--rollback DELETE FROM user_status;
@@ -0,0 +1,69 @@
+package rove
+
+import (
+	"fmt"
+
+	"github.com/josephspurrier/rove/pkg/changeset"
+)
+
+// transactionalDDL returns true if the changelog supports transactional DDL.
+func transactionalDDL(db Changelog) bool {
+	t, ok := db.(TransactionalDDL)
+	return ok && t.TransactionalDDL()
+}
+
+// dialect returns the SQL syntax of the changelog to split statements.
+func dialect(db Changelog) changeset.Dialect {
+	if d, ok := db.(StatementDialect); ok {
+		return d.Dialect()
+	}
+
+	return changeset.DialectStandard
+}
+
+// execStatements returns the func to run each statement in a transaction.
+func execStatements(statements []changeset.Statement) func(tx Transaction) error {
+	return func(tx Transaction) error {
+		for i, st := range statements {
+			err := tx.Exec(st.Query)
+			if err != nil {
+				return fmt.Errorf("error on statement %v (line %v) - %v", i+1, st.Line, err.Error())
+			}
+		}
+
+		return nil
+	}
+}
+
+// execTx will run the changes and then the changelog operation in a single
+// transaction. The transaction is rolled back if any fail.
+func (r *Rove) execTx(changes func(tx Transaction) error, changelog func(tx Transaction) error) error {
+	tx, err := r.db.BeginTx()
+	if err != nil {
+		return fmt.Errorf("error on begin transaction - %v", err.Error())
+	}
+
+	// Execute the changes and then update the changelog.
+	err = changes(tx)
+	if err == nil {
+		err = changelog(tx)
+	}
+	if err != nil {
+		errr := tx.Rollback()
+		if errr != nil {
+			return fmt.Errorf("error on rollback - %v (%v)", errr.Error(), err.Error())
+		}
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		errr := tx.Rollback()
+		if errr != nil {
+			return fmt.Errorf("error on commit rollback - %v", errr.Error())
+		}
+		return fmt.Errorf("error on commit - %v", err.Error())
+	}
+
+	return nil
+}